@@ -0,0 +1,60 @@
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-easygen/go-flags/clis"
+)
+
+// *** Sub-command: batch ***
+
+////////////////////////////////////////////////////////////////////////////
+// Constant and data type/structure definitions
+
+// The BatchCommand type defines all the configurable options from cli.
+type BatchCommand struct {
+	Manifest    string `short:"i" long:"manifest" env:"SOXCUT_MANIFEST" description:"the manifest of jobs, a CSV or YAML file (mandatory)" required:"true"`
+	Concurrency int    `short:"c" long:"concurrency" env:"SOXCUT_CONCURRENCY" description:"how many jobs to run at once, 0 for the number of CPUs"`
+}
+
+var batchCommand BatchCommand
+
+////////////////////////////////////////////////////////////////////////////
+// Function definitions
+
+func init() {
+	gfParser.AddCommand("batch",
+		"run the extract and splice jobs of a manifest",
+		`Example:
+  soxcut batch -i episodes.csv
+  soxcut -v batch -i episodes.yaml -c 4
+
+`,
+		&batchCommand)
+}
+
+func (x *BatchCommand) Execute(args []string) error {
+	fmt.Fprintf(os.Stderr, "run the extract and splice jobs of a manifest\n")
+	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
+	clis.Setup("soxcut::batch", Opts.Verbose)
+	setupLogging(Opts.Verbose, Opts.LogFormat)
+	clis.Verbose(1, "Doing Batch, with %+v, %+v", Opts, args)
+	// fmt.Println(x.Manifest, x.Concurrency)
+	return x.Exec(args)
+}
+
+// // Exec implements the business logic of command `batch`
+// func (x *BatchCommand) Exec(args []string) error {
+// 	// err := ...
+// 	// clis.WarnOn("batch::Exec", err)
+// 	// or,
+// 	// clis.AbortOn("batch::Exec", err)
+// 	return nil
+// }
@@ -0,0 +1,60 @@
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-easygen/go-flags/clis"
+)
+
+// *** Sub-command: cache ***
+
+////////////////////////////////////////////////////////////////////////////
+// Constant and data type/structure definitions
+
+// The CacheCommand type defines all the configurable options from cli.
+type CacheCommand struct {
+	MaxAge  int `short:"a" long:"max-age" env:"SOXCUT_MAXAGE" description:"remove cached files not used for this many days (0 for no limit)" default:"30"`
+	MaxSize int `short:"s" long:"max-size" env:"SOXCUT_MAXSIZE" description:"remove least recently used files till the cache is within this many MB (0 for no limit)"`
+}
+
+var cacheCommand CacheCommand
+
+////////////////////////////////////////////////////////////////////////////
+// Function definitions
+
+func init() {
+	gfParser.AddCommand("cache",
+		"manage the cache of intermediate files",
+		`Example:
+  soxcut cache -C <cacheDir> prune [-a <days>] [-s <MB>]
+  soxcut cache -C ~/.cache/soxcut prune -a 7 -s 2000
+
+`,
+		&cacheCommand)
+}
+
+func (x *CacheCommand) Execute(args []string) error {
+	fmt.Fprintf(os.Stderr, "manage the cache of intermediate files\n")
+	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
+	clis.Setup("soxcut::cache", Opts.Verbose)
+	setupLogging(Opts.Verbose, Opts.LogFormat)
+	clis.Verbose(1, "Doing Cache, with %+v, %+v", Opts, args)
+	// fmt.Println(x.MaxAge, x.MaxSize)
+	return x.Exec(args)
+}
+
+// // Exec implements the business logic of command `cache`
+// func (x *CacheCommand) Exec(args []string) error {
+// 	// err := ...
+// 	// clis.WarnOn("cache::Exec", err)
+// 	// or,
+// 	// clis.AbortOn("cache::Exec", err)
+// 	return nil
+// }
@@ -0,0 +1,62 @@
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-easygen/go-flags/clis"
+)
+
+// *** Sub-command: check-joints ***
+
+////////////////////////////////////////////////////////////////////////////
+// Constant and data type/structure definitions
+
+// The CheckJointsCommand type defines all the configurable options from cli.
+type CheckJointsCommand struct {
+	Rendered  string  `short:"i" long:"rendered" env:"SOXCUT_RENDERED" description:"the rendered output to check (mandatory)" required:"true"`
+	MapFile   string  `short:"t" long:"map" env:"SOXCUT_MAPFILE" description:"the timeline map of the rendered output, next to it by default"`
+	Window    int     `short:"w" long:"window" env:"SOXCUT_WINDOW" description:"duration to compare before and after each cross-fade in ms" default:"500"`
+	Threshold float64 `short:"s" long:"threshold" env:"SOXCUT_THRESHOLD" description:"the score from which a joint is flagged as suspicious" default:"1"`
+}
+
+var checkJointsCommand CheckJointsCommand
+
+////////////////////////////////////////////////////////////////////////////
+// Function definitions
+
+func init() {
+	gfParser.AddCommand("check-joints",
+		"inspect each joint of the rendered output for audible splices",
+		`Example:
+  soxcut check-joints -i <renderedFile> [-t <timelineFile>] [-w <ms>] [-s <score>]
+  soxcut extract -i input1.wav -s timings.txt -o output.wav --timeline && soxcut check-joints -i output.wav
+
+`,
+		&checkJointsCommand)
+}
+
+func (x *CheckJointsCommand) Execute(args []string) error {
+	fmt.Fprintf(os.Stderr, "inspect each joint of the rendered output for audible splices\n")
+	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
+	clis.Setup("soxcut::check-joints", Opts.Verbose)
+	setupLogging(Opts.Verbose, Opts.LogFormat)
+	clis.Verbose(1, "Doing CheckJoints, with %+v, %+v", Opts, args)
+	// fmt.Println(x.Rendered, x.MapFile, x.Window, x.Threshold)
+	return x.Exec(args)
+}
+
+// // Exec implements the business logic of command `check-joints`
+// func (x *CheckJointsCommand) Exec(args []string) error {
+// 	// err := ...
+// 	// clis.WarnOn("check-joints::Exec", err)
+// 	// or,
+// 	// clis.AbortOn("check-joints::Exec", err)
+// 	return nil
+// }
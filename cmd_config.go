@@ -0,0 +1,59 @@
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-easygen/go-flags/clis"
+)
+
+// *** Sub-command: config ***
+
+////////////////////////////////////////////////////////////////////////////
+// Constant and data type/structure definitions
+
+// The ConfigCommand type defines all the configurable options from cli.
+type ConfigCommand struct {
+}
+
+var configCommand ConfigCommand
+
+////////////////////////////////////////////////////////////////////////////
+// Function definitions
+
+func init() {
+	gfParser.AddCommand("config",
+		"show the effective configuration",
+		`Example:
+  soxcut config show
+  soxcut -E 300 config show
+  soxcut config show check-joints
+
+`,
+		&configCommand)
+}
+
+func (x *ConfigCommand) Execute(args []string) error {
+	fmt.Fprintf(os.Stderr, "show the effective configuration\n")
+	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
+	clis.Setup("soxcut::config", Opts.Verbose)
+	setupLogging(Opts.Verbose, Opts.LogFormat)
+	clis.Verbose(1, "Doing Config, with %+v, %+v", Opts, args)
+	// fmt.Println()
+	return x.Exec(args)
+}
+
+// // Exec implements the business logic of command `config`
+// func (x *ConfigCommand) Exec(args []string) error {
+// 	// err := ...
+// 	// clis.WarnOn("config::Exec", err)
+// 	// or,
+// 	// clis.AbortOn("config::Exec", err)
+// 	return nil
+// }
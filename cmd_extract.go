@@ -20,8 +20,11 @@ import (
 
 // The ExtractCommand type defines all the configurable options from cli.
 type ExtractCommand struct {
-	FileI string `short:"i" long:"input" env:"SOXCUT_FILEI" description:"the source to cut from (mandatory)" required:"true"`
-	FileS string `short:"s" long:"segments" env:"SOXCUT_FILES" description:"the segments definition file (mandatory)" required:"true"`
+	FileI   string `short:"i" long:"input" env:"SOXCUT_FILEI" description:"the source to cut from"`
+	FileS   string `short:"s" long:"segments" env:"SOXCUT_FILES" description:"the segments definition file"`
+	Intro   string `short:"I" long:"intro" env:"SOXCUT_INTRO" description:"the jingle to attach to the start, its tail running under the speech"`
+	Outro   string `short:"O" long:"outro" env:"SOXCUT_OUTRO" description:"the jingle to attach to the end, its head running under the speech"`
+	Overlap int    `short:"X" long:"overlap" env:"SOXCUT_OVERLAP" description:"how long the intro and outro overlap the speech in ms" default:"2000"`
 }
 
 var extractCommand ExtractCommand
@@ -36,6 +39,7 @@ func init() {
   soxcut extract -i <inputFile> -s <segmentsFile> -o <outputFile> [sox_effects...]
   soxcut extract -i input1.wav -s timings.txt -o output.mp3 -f="-C 128"
   soxcut extract -i audio.flac -s timings.txt -o final.opus -f="-C 16" -v -- gain -n highpass 80 pad 0 5
+  soxcut extract -i audio.flac -s timings.txt -o "show.mp3:-C 128" -o "show.opus:-C 48" -o master.flac
 
 `,
 		&extractCommand)
@@ -45,8 +49,9 @@ func (x *ExtractCommand) Execute(args []string) error {
 	fmt.Fprintf(os.Stderr, "extract segments from source and splice them for smooth transition\n")
 	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
 	clis.Setup("soxcut::extract", Opts.Verbose)
+	setupLogging(Opts.Verbose, Opts.LogFormat)
 	clis.Verbose(1, "Doing Extract, with %+v, %+v", Opts, args)
-	// fmt.Println(x.FileI, x.FileS)
+	// fmt.Println(x.FileI, x.FileS, x.Intro, x.Outro, x.Overlap)
 	return x.Exec(args)
 }
 
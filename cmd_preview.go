@@ -0,0 +1,68 @@
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-easygen/go-flags/clis"
+)
+
+// *** Sub-command: preview ***
+
+////////////////////////////////////////////////////////////////////////////
+// Constant and data type/structure definitions
+
+// The PreviewCommand type defines all the configurable options from cli.
+type PreviewCommand struct {
+	FileI     string `short:"i" long:"input" env:"SOXCUT_FILEI" description:"the source to cut from"`
+	FileS     string `short:"s" long:"segments" env:"SOXCUT_FILES" description:"the segments definition file"`
+	FileList  string `short:"l" long:"list" env:"SOXCUT_FILELIST" description:"the list file or M3U/PLS playlist containing sources to splice"`
+	Dir       string `short:"d" long:"dir" env:"SOXCUT_DIR" description:"the directory or glob pattern of sources to splice, in natural sort order"`
+	Before    int    `short:"b" long:"before" env:"SOXCUT_BEFORE" description:"duration to render before each joint in ms" default:"3000"`
+	After     int    `short:"a" long:"after" env:"SOXCUT_AFTER" description:"duration to render after each joint in ms" default:"3000"`
+	Separator string `short:"p" long:"separator" env:"SOXCUT_SEPARATOR" description:"what to put between the joint previews, beep or silence" default:"beep"`
+	Split     bool   `short:"S" long:"split" env:"SOXCUT_SPLIT" description:"write one numbered output file per joint instead"`
+}
+
+var previewCommand PreviewCommand
+
+////////////////////////////////////////////////////////////////////////////
+// Function definitions
+
+func init() {
+	gfParser.AddCommand("preview",
+		"render only the audio around each joint for quick auditioning",
+		`Example:
+  soxcut preview -i <inputFile> -s <segmentsFile> [-o <outputFile>] [sox_effects...]
+  soxcut preview -l <listFile> [-b <ms>] [-a <ms>] [-p beep|silence] [-S] [-o <outputFile>]
+  soxcut preview -i input1.wav -s timings.txt -b 2000 -a 2000 -o joints.mp3
+  soxcut preview -l test/sources.lst -S -o joint.wav
+
+`,
+		&previewCommand)
+}
+
+func (x *PreviewCommand) Execute(args []string) error {
+	fmt.Fprintf(os.Stderr, "render only the audio around each joint for quick auditioning\n")
+	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
+	clis.Setup("soxcut::preview", Opts.Verbose)
+	setupLogging(Opts.Verbose, Opts.LogFormat)
+	clis.Verbose(1, "Doing Preview, with %+v, %+v", Opts, args)
+	// fmt.Println(x.FileI, x.FileS, x.FileList, x.Dir, x.Before, x.After, x.Separator, x.Split)
+	return x.Exec(args)
+}
+
+// // Exec implements the business logic of command `preview`
+// func (x *PreviewCommand) Exec(args []string) error {
+// 	// err := ...
+// 	// clis.WarnOn("preview::Exec", err)
+// 	// or,
+// 	// clis.AbortOn("preview::Exec", err)
+// 	return nil
+// }
@@ -20,7 +20,14 @@ import (
 
 // The SpliceCommand type defines all the configurable options from cli.
 type SpliceCommand struct {
-	FileList string `short:"l" long:"list" env:"SOXCUT_FILELIST" description:"the list file containing sources to splice (mandatory)" required:"true"`
+	FileList string `short:"l" long:"list" env:"SOXCUT_FILELIST" description:"the list file or M3U/PLS playlist containing sources to splice"`
+	Dir      string `short:"d" long:"dir" env:"SOXCUT_DIR" description:"the directory or glob pattern of sources to splice, in natural sort order"`
+	Rate     int    `short:"r" long:"rate" env:"SOXCUT_RATE" description:"the sample rate to splice at, highest of the sources by default"`
+	Channels int    `short:"c" long:"channels" env:"SOXCUT_CHANNELS" description:"the number of channels to splice with, highest of the sources by default"`
+	Bits     int    `short:"b" long:"bits" env:"SOXCUT_BITS" description:"the bit depth to splice with, highest of the sources by default"`
+	Intro    string `short:"I" long:"intro" env:"SOXCUT_INTRO" description:"the jingle to attach to the start, its tail running under the speech"`
+	Outro    string `short:"O" long:"outro" env:"SOXCUT_OUTRO" description:"the jingle to attach to the end, its head running under the speech"`
+	Overlap  int    `short:"X" long:"overlap" env:"SOXCUT_OVERLAP" description:"how long the intro and outro overlap the speech in ms" default:"2000"`
 }
 
 var spliceCommand SpliceCommand
@@ -34,6 +41,8 @@ func init() {
 		`Example:
   soxcut splice -l <listFile> [-o <outputFile>] [sox_effects...]
   soxcut splice -l audio-files.lst -E 200 -L 100 -- compand 0.3,1 6:-70,-60,-20,-10,-5,-5 0 -90 0.1 rate 96k pad 0.5 15
+  soxcut splice -l test/sources.lst -o show.mp3
+  soxcut splice -d 'parts/part-*.flac' -o show.flac
 
 `,
 		&spliceCommand)
@@ -43,8 +52,9 @@ func (x *SpliceCommand) Execute(args []string) error {
 	fmt.Fprintf(os.Stderr, "splice sources for smooth transition\n")
 	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
 	clis.Setup("soxcut::splice", Opts.Verbose)
+	setupLogging(Opts.Verbose, Opts.LogFormat)
 	clis.Verbose(1, "Doing Splice, with %+v, %+v", Opts, args)
-	// fmt.Println(x.FileList)
+	// fmt.Println(x.FileList, x.Dir, x.Rate, x.Channels, x.Bits, x.Intro, x.Outro, x.Overlap)
 	return x.Exec(args)
 }
 
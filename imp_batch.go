@@ -0,0 +1,18 @@
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+package main
+
+// *** Sub-command: batch ***
+// Exec implements the business logic of command `batch`
+func (x *BatchCommand) Exec(args []string) error {
+	// err := ...
+	// clis.WarnOn("batch::Exec", err)
+	// or,
+	// clis.AbortOn("batch::Exec", err)
+	soxbatch(x.Manifest, x.Concurrency)
+	return nil
+}
@@ -0,0 +1,25 @@
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// *** Sub-command: cache ***
+// Exec implements the business logic of command `cache`
+func (x *CacheCommand) Exec(args []string) error {
+	if Opts.CacheDir == "" {
+		return fmt.Errorf("the cache directory (-C) is required")
+	}
+	if len(args) != 1 || args[0] != "prune" {
+		return fmt.Errorf("unknown cache action %q, expected: prune", args)
+	}
+	return pruneCache(Opts.CacheDir,
+		time.Duration(x.MaxAge)*24*time.Hour, int64(x.MaxSize)*1000*1000)
+}
@@ -0,0 +1,18 @@
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+package main
+
+// *** Sub-command: check-joints ***
+// Exec implements the business logic of command `check-joints`
+func (x *CheckJointsCommand) Exec(args []string) error {
+	// err := ...
+	// clis.WarnOn("check-joints::Exec", err)
+	// or,
+	// clis.AbortOn("check-joints::Exec", err)
+	soxcheck()
+	return nil
+}
@@ -0,0 +1,27 @@
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+package main
+
+import (
+	"fmt"
+)
+
+// *** Sub-command: config ***
+// Exec implements the business logic of command `config`
+func (x *ConfigCommand) Exec(args []string) error {
+	if len(args) < 1 || len(args) > 2 || args[0] != "show" {
+		return fmt.Errorf("unknown config action %q, expected: show [command]", args)
+	}
+	command := gfParser.Active
+	if len(args) == 2 {
+		if command = gfParser.Find(args[1]); command == nil {
+			return fmt.Errorf("unknown command '%s'", args[1])
+		}
+	}
+	showConfig(command)
+	return nil
+}
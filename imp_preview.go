@@ -0,0 +1,18 @@
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+package main
+
+// *** Sub-command: preview ***
+// Exec implements the business logic of command `preview`
+func (x *PreviewCommand) Exec(args []string) error {
+	// err := ...
+	// clis.WarnOn("preview::Exec", err)
+	// or,
+	// clis.AbortOn("preview::Exec", err)
+	soxpreview(args)
+	return nil
+}
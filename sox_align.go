@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Splice point search backends.
+const (
+	searchNative = "native"
+	searchSox    = "sox"
+)
+
+// envelopeFrame is the frame duration of the envelope similarity.
+const envelopeFrame = 10 * time.Millisecond
+
+// ..........................................................................
+// alignNextClip finds the best splice point for the next clip within the
+// joint leeway, or takes the forced one, and trims the next clip so that
+// the splice effect can join it with no further search. The chosen offset,
+// relative to the ideal splice point, is recorded in joint.
+func alignNextClip(combinedFile, nextClip string, joint *Joint, splicePos time.Duration, outPath string) (string, error) {
+	format, err := getAudioFormat(combinedFile)
+	if err != nil {
+		return "", fmt.Errorf("could not get format of '%s': %v", combinedFile, err)
+	}
+	rate := float64(format.Rate)
+	toSamples := func(d time.Duration) int { return int(math.Round(d.Seconds() * rate)) }
+	toDuration := func(n int) time.Duration { return time.Duration(float64(n) / rate * float64(time.Second)) }
+
+	// The next clip starts with the leeway either side of the ideal point,
+	// so a trim of one leeway is the ideal splice point.
+	leeway := toSamples(joint.Leeway)
+	trim := leeway
+	if joint.Forced {
+		trim = min(max(leeway+toSamples(joint.Offset), 0), 2*leeway)
+	} else {
+		// Compare the excess on the end of the combined file with each
+		// candidate start of the next clip.
+		tail, err := readSamples(combinedFile, format, max(splicePos-joint.Excess, 0), joint.Excess)
+		if err != nil {
+			return "", err
+		}
+		head, err := readSamples(nextClip, format, 0, 2*joint.Leeway+joint.Excess)
+		if err != nil {
+			return "", err
+		}
+		frameSize := int(envelopeFrame.Seconds() * rate)
+		trim = bestOffset(mixdown(tail), mixdown(head), 2*leeway, frameSize, Opts.EnvelopeWeight)
+	}
+	joint.Offset = toDuration(trim - leeway)
+
+	if trim == 0 {
+		return nextClip, nil
+	}
+	alignedPath := strings.TrimSuffix(outPath, ".wav") + "_aligned.wav"
+	return trimClip(nextClip, alignedPath, toDuration(trim), 0)
+}
+
+// ..........................................................................
+// bestOffset returns the shift, from 0 to maxShift samples, at which b best
+// matches a: by normalised cross-correlation, blended with the similarity
+// of their level envelopes by the weight. Ties go to the middle shift.
+func bestOffset(a, b []float64, maxShift, frameSize int, weight float64) int {
+	maxShift = min(maxShift, len(b)-len(a))
+	if maxShift <= 0 || len(a) == 0 {
+		return max(maxShift/2, 0)
+	}
+	corr := crossCorrelate(a, b, maxShift)
+
+	// Prefix sums of squares for the energy of any window of b.
+	prefix := make([]float64, len(b)+1)
+	for i, v := range b {
+		prefix[i+1] = prefix[i] + v*v
+	}
+	energy := func(start, n int) float64 { return prefix[start+n] - prefix[start] }
+	energyA := 0.0
+	for _, v := range a {
+		energyA += v * v
+	}
+	var envA []float64
+	if weight > 0 && frameSize > 0 {
+		for f := 0; f+frameSize <= len(a); f += frameSize {
+			envA = append(envA, rmsDB(a[f:f+frameSize]))
+		}
+	}
+
+	best, bestScore := maxShift/2, math.Inf(-1)
+	for shift := 0; shift <= maxShift; shift++ {
+		score := 0.0
+		if norm := math.Sqrt(energyA * energy(shift, len(a))); norm > 0 {
+			score = corr[shift] / norm
+		}
+		if len(envA) > 0 {
+			// Similarity of 1 for identical envelopes, down to 0 for 20 dB apart.
+			var diff float64
+			for f, levelA := range envA {
+				ms := energy(shift+f*frameSize, frameSize) / float64(frameSize)
+				levelB := max(10*math.Log10(ms), silenceDB)
+				diff += math.Abs(levelA - levelB)
+			}
+			similarity := max(1-diff/float64(len(envA))/20, 0)
+			score = (1-weight)*score + weight*similarity
+		}
+		if score > bestScore || (score == bestScore && abs(shift-maxShift/2) < abs(best-maxShift/2)) {
+			best, bestScore = shift, score
+		}
+	}
+	return best
+}
+
+// crossCorrelate returns sum(a[k]*b[k+shift]) for each shift from 0 to
+// maxShift, computed with the FFT.
+func crossCorrelate(a, b []float64, maxShift int) []float64 {
+	n := 1
+	for n < len(a)+len(b) {
+		n <<= 1
+	}
+	fa := make([]complex128, n)
+	fb := make([]complex128, n)
+	for i, v := range a {
+		fa[i] = complex(v, 0)
+	}
+	for i, v := range b {
+		fb[i] = complex(v, 0)
+	}
+	fft(fa)
+	fft(fb)
+	// The inverse transform of conj(A)*B, as conj(fft(conj(X)))/n.
+	for i := range fa {
+		x := complex(real(fa[i]), -imag(fa[i])) * fb[i]
+		fa[i] = complex(real(x), -imag(x))
+	}
+	fft(fa)
+	corr := make([]float64, maxShift+1)
+	for shift := range corr {
+		corr[shift] = real(fa[shift]) / float64(n)
+	}
+	return corr
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ..........................................................................
+// forceOffsets returns the joints for clipCount clips, with the offsets
+// forced by the cli applied. Each forced offset is given as N=ms, for the
+// joint before clip N, and has to be within the leeway of the joint.
+func forceOffsets(joints []Joint, clipCount int, forced []string) ([]Joint, error) {
+	if len(forced) == 0 {
+		return joints, nil
+	}
+	all := make([]Joint, max(clipCount-1, 0))
+	for i := range all {
+		all[i] = jointAt(joints, i)
+	}
+	for _, f := range forced {
+		clip, ms, ok := strings.Cut(f, "=")
+		n, err := strconv.Atoi(clip)
+		if !ok || err != nil || n < 2 || n > clipCount {
+			return nil, fmt.Errorf("invalid forced offset '%s', expected N=ms with N from 2 to %d", f, clipCount)
+		}
+		offset, err := strconv.ParseFloat(ms, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid forced offset '%s': %w", f, err)
+		}
+		all[n-2].Offset = time.Duration(offset * float64(time.Millisecond))
+		all[n-2].Forced = true
+		if leeway := all[n-2].Leeway; all[n-2].Offset < -leeway || all[n-2].Offset > leeway {
+			return nil, fmt.Errorf("forced offset '%s' of the joint before clip %d is beyond its leeway of %v",
+				f, n, leeway)
+		}
+		slog.Debug("Forcing the splice offset", "clip", n, "offset", all[n-2].Offset)
+	}
+	return all, nil
+}
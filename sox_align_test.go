@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestBestOffset(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	noise := func(n int) []float64 {
+		samples := make([]float64, n)
+		for i := range samples {
+			samples[i] = rng.Float64()*2 - 1
+		}
+		return samples
+	}
+
+	const length, maxShift, frameSize = 400, 80, 40
+	tests := []struct {
+		name   string
+		shift  int
+		weight float64
+	}{
+		{"no shift", 0, 0},
+		{"shifted", 37, 0},
+		{"max shift", maxShift, 0},
+		{"with envelope", 23, 0.5},
+	}
+	for _, tt := range tests {
+		// b holds a starting shift samples in, amid noise.
+		a := noise(length)
+		b := noise(length + maxShift)
+		copy(b[tt.shift:], a)
+		if got := bestOffset(a, b, maxShift, frameSize, tt.weight); got != tt.shift {
+			t.Errorf("%s: bestOffset() = %d, want %d", tt.name, got, tt.shift)
+		}
+	}
+
+	// With nothing to compare, the middle shift.
+	if got := bestOffset(nil, noise(100), 40, frameSize, 0); got != 20 {
+		t.Errorf("bestOffset() of nothing = %d, want 20", got)
+	}
+}
+
+func TestForceOffsets(t *testing.T) {
+	joints := []Joint{
+		{Excess: excessDuration, Leeway: 200 * time.Millisecond},
+		{Excess: excessDuration, Leeway: 0},
+	}
+	got, err := forceOffsets(joints, 4, []string{"2=-150", "4=200"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("forceOffsets() returned %d joints, want 3", len(got))
+	}
+	if !got[0].Forced || got[0].Offset != -150*time.Millisecond {
+		t.Errorf("joint before clip 2 = %+v, want forced to -150ms", got[0])
+	}
+	if got[1].Forced {
+		t.Errorf("joint before clip 3 = %+v, want it not forced", got[1])
+	}
+	if !got[2].Forced || got[2].Offset != 200*time.Millisecond || got[2].Leeway != leewayDuration {
+		t.Errorf("joint before clip 4 = %+v, want the default, forced to 200ms", got[2])
+	}
+
+	for _, forced := range []string{"2=250", "2=-201", "3=10", "1=0", "5=0", "2", "x=5", "2=ms"} {
+		if _, err := forceOffsets(joints, 4, []string{forced}); err == nil {
+			t.Errorf("forceOffsets(%q) succeeded, want an error", forced)
+		}
+	}
+	if _, err := forceOffsets(joints, 4, []string{"3=0"}); err != nil {
+		t.Errorf("forceOffsets(\"3=0\") with no leeway: %v", err)
+	}
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// batchColumns are the columns of a CSV batch manifest, named in its header.
+var batchColumns = []string{"input", "segments", "output", "options"}
+
+// batchPathOptions are the soxcut options taking a path, which are relative
+// to the manifest in the job options, as the paths of the columns are.
+var batchPathOptions = map[string]bool{
+	"-o": true, "--output": true,
+	"-m": true, "--bed": true,
+	"-C": true, "--cache-dir": true,
+	"-U": true, "--work-dir": true,
+	"-Y": true, "--report": true,
+	"-I": true, "--intro": true,
+	"-O": true, "--outro": true,
+}
+
+// BatchJob is a single job of a batch manifest: extracting the segments from
+// the input, or splicing the sources of the input list file, directory or
+// glob pattern when no segments are given, into the output, with the extra
+// soxcut options and sox effects of the job, split as by the shell, e.g.
+// "-E 300 --bed 'theme song.mp3' -- gain -1".
+type BatchJob struct {
+	Input    string `yaml:"input"`
+	Segments string `yaml:"segments"`
+	Output   string `yaml:"output"`
+	Options  string `yaml:"options"`
+
+	args []string // the options split, with their paths relative to the manifest
+}
+
+// BatchResult is the outcome of a job.
+type BatchResult struct {
+	Job      BatchJob
+	Duration time.Duration
+	Err      error
+}
+
+// ..........................................................................
+// soxbatch runs the jobs of the manifest, concurrency of them at once, each
+// as a soxcut of its own, carrying on past the failed ones, and prints a
+// summary of them all.
+func soxbatch(manifest string, concurrency int) {
+	// The jobs running at once would share them.
+	if Opts.WorkDir != "" || Opts.Resume || Opts.Report != "" {
+		log.Fatal("--work-dir, --resume and --report apply to a single job, give them in the options of the jobs instead.")
+	}
+	jobs, err := readBatchManifest(manifest)
+	if err != nil {
+		log.Fatalf("Error reading batch manifest '%s': %v", manifest, err)
+	}
+	if len(jobs) == 0 {
+		log.Fatal("No jobs found in the manifest. Exiting.")
+	}
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatalf("Could not find the soxcut executable: %v", err)
+	}
+	slog.Info("Batch started", "jobs", len(jobs), "concurrency", concurrency)
+	startProgress(Opts.Progress)
+	progress.Phase("batch", len(jobs))
+
+	env := batchEnv()
+	results := make([]BatchResult, len(jobs))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			slog.Info("Job started", "job", i+1, "output", job.Output)
+			start := time.Now()
+			err := runBatchJob(exe, env, job)
+			results[i] = BatchResult{Job: job, Duration: time.Since(start), Err: err}
+			if err != nil {
+				slog.Error("Job failed", "job", i+1, "output", job.Output, "err", err)
+			} else {
+				slog.Info("Job done", "job", i+1, "output", job.Output)
+			}
+			progress.Step(0, 0, "")
+		}()
+	}
+	wg.Wait()
+	progress.Finish()
+
+	failed := 0
+	fmt.Printf("%4s  %-6s %8s  %s\n", "Job", "Status", "Duration", "Output")
+	for i, result := range results {
+		status, reason := "ok", ""
+		if result.Err != nil {
+			status, reason = "FAILED", "  ("+result.Err.Error()+")"
+			failed++
+		}
+		fmt.Printf("%4d  %-6s %8s  %s%s\n", i+1, status, formatETA(result.Duration),
+			result.Job.Output, reason)
+	}
+	if failed > 0 {
+		log.Fatalf("%d of %d job(s) failed.", failed, len(jobs))
+	}
+	slog.Info("All jobs done", "jobs", len(jobs))
+}
+
+// runBatchJob runs the job with the soxcut executable, in the environment.
+func runBatchJob(exe string, env []string, job BatchJob) error {
+	args := []string{"--progress", progressNone, "--log-format", logJSON}
+	for i := 0; i < Opts.Verbose; i++ {
+		args = append(args, "-v")
+	}
+	if job.Segments != "" {
+		args = append(args, "extract", "-i", job.Input, "-s", job.Segments)
+	} else if info, err := os.Stat(job.Input); (err == nil && info.IsDir()) || strings.ContainsAny(job.Input, "*?[") {
+		args = append(args, "splice", "-d", job.Input)
+	} else {
+		args = append(args, "splice", "-l", job.Input)
+	}
+	args = append(args, "-o", job.Output)
+	args = append(args, job.args...)
+
+	cmd := newCommand(exe, args...)
+	terminateOnCancel(cmd)
+	cmd.Env = env
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	start := logCommand(cmd)
+	err := startCommand(cmd)
+	if err == nil {
+		err = waitCommand(cmd)
+	}
+	logCommandDone(cmd, start, output.Bytes(), err)
+	if err != nil {
+		// The whole output is logged at debug level, the error is the message
+		// of its last record.
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		var record struct{ Msg string }
+		if json.Unmarshal([]byte(lines[len(lines)-1]), &record) == nil && record.Msg != "" {
+			return fmt.Errorf("%v: %s", err, record.Msg)
+		}
+		return fmt.Errorf("%v: %s", err, lines[len(lines)-1])
+	}
+	return nil
+}
+
+// batchEnv returns the environment of the jobs, passing the global options
+// set by flag to the batch on to them as their environment defaults.
+func batchEnv() []string {
+	env := os.Environ()
+	for _, option := range groupOptions(gfParser.Group) {
+		if key := option.EnvDefaultKey; key != "" && option.IsSet() && !option.IsSetDefault() {
+			env = append(env, key+"="+optionValue(option))
+		}
+	}
+	return env
+}
+
+// ..........................................................................
+// readBatchManifest reads the jobs of the CSV or YAML manifest, by its
+// extension. A CSV manifest starts with a header naming its columns, out of
+// batchColumns; a YAML one is a list of jobs with the same keys. Relative
+// paths are relative to the manifest.
+func readBatchManifest(path string) ([]BatchJob, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []BatchJob
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		jobs, err = parseBatchCSV(data)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &jobs)
+	default:
+		return nil, fmt.Errorf("unknown manifest format '%s', expected .csv, .yaml or .yml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Dir(path)
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Input == "" || job.Output == "" {
+			return nil, fmt.Errorf("job %d: input and output are required", i+1)
+		}
+		for _, p := range []*string{&job.Input, &job.Segments, &job.Output} {
+			*p = rebasePath(base, *p)
+		}
+		if job.args, err = splitOptions(job.Options); err != nil {
+			return nil, fmt.Errorf("job %d: invalid options: %v", i+1, err)
+		}
+		rebaseOptions(base, job.args)
+	}
+	return jobs, nil
+}
+
+// rebasePath returns the path relative to the base directory, leaving alone
+// the absolute paths and the sox special outputs, like - for stdout.
+func rebasePath(base, path string) string {
+	if path == "" || filepath.IsAbs(path) || !isFileOutput(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
+
+// rebaseOptions rebases the paths given to the batchPathOptions in the
+// soxcut options, up to the sox effects after a "--", in place.
+func rebaseOptions(base string, args []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return
+		}
+		name, value, joined := arg, "", false
+		if strings.HasPrefix(arg, "--") {
+			name, value, joined = strings.Cut(arg, "=")
+		} else if len(arg) > 2 && arg[0] == '-' {
+			name, value, joined = arg[:2], arg[2:], true
+		}
+		if !batchPathOptions[name] {
+			continue
+		}
+		if joined {
+			args[i] = strings.TrimSuffix(arg, value) + rebasePath(base, value)
+		} else if i+1 < len(args) {
+			i++
+			args[i] = rebasePath(base, args[i])
+		}
+	}
+}
+
+// splitOptions splits the options into arguments as the shell does, at
+// unquoted white space, with single and double quotes. Outside of single
+// quotes, a backslash escapes a quote, backslash or space, and is taken as
+// is before anything else, for Windows paths.
+func splitOptions(s string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && quote != '\'' && i+1 < len(runes) &&
+			(strings.ContainsRune(`\'"`, runes[i+1]) || (quote == 0 && unicode.IsSpace(runes[i+1]))):
+			i++
+			arg.WriteRune(runes[i])
+			inArg = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// parseBatchCSV parses the jobs of a CSV manifest, skipping # comments.
+func parseBatchCSV(data []byte) ([]BatchJob, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		known := false
+		for _, column := range batchColumns {
+			known = known || name == column
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column '%s', expected: %s", name, strings.Join(batchColumns, ", "))
+		}
+		columns[name] = i
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var jobs []BatchJob
+	for _, record := range records[1:] {
+		jobs = append(jobs, BatchJob{
+			Input:    field(record, "input"),
+			Segments: field(record, "segments"),
+			Output:   field(record, "output"),
+			Options:  field(record, "options"),
+		})
+	}
+	return jobs, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitOptions(t *testing.T) {
+	tests := []struct {
+		options string
+		want    []string
+	}{
+		{"", nil},
+		{"  -E 300\t--fade-in 500 ", []string{"-E", "300", "--fade-in", "500"}},
+		{`--bed 'theme song.mp3' -- gain -1`, []string{"--bed", "theme song.mp3", "--", "gain", "-1"}},
+		{`--bed "it's.mp3"`, []string{"--bed", "it's.mp3"}},
+		{`--bed theme\ song.mp3`, []string{"--bed", "theme song.mp3"}},
+		{`--bed=""`, []string{"--bed="}},
+		{`-m C:\music\bed.mp3`, []string{"-m", `C:\music\bed.mp3`}},
+		{`-m 'C:\music\bed.mp3'`, []string{"-m", `C:\music\bed.mp3`}},
+		{`--intro "say \"hi\".wav"`, []string{"--intro", `say "hi".wav`}},
+	}
+	for _, tt := range tests {
+		got, err := splitOptions(tt.options)
+		if err != nil {
+			t.Errorf("splitOptions(%q): %v", tt.options, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitOptions(%q) = %q, want %q", tt.options, got, tt.want)
+		}
+	}
+
+	for _, options := range []string{`--bed 'theme.mp3`, `--bed "theme.mp3`} {
+		if _, err := splitOptions(options); err == nil {
+			t.Errorf("splitOptions(%q) succeeded, want an error", options)
+		}
+	}
+}
+
+func TestRebaseOptions(t *testing.T) {
+	args := []string{
+		"-E", "300", "--bed", "bed.mp3", "-m", "/abs/bed.mp3", "--intro=intro.wav",
+		"-Ooutro.wav", "-o", "-", "-o", "ep.flac:-C 8", "-R", "00:01-00:02",
+		"--", "-m", "not-a-path",
+	}
+	rebaseOptions("shows", args)
+	want := []string{
+		"-E", "300", "--bed", "shows/bed.mp3", "-m", "/abs/bed.mp3", "--intro=shows/intro.wav",
+		"-Oshows/outro.wav", "-o", "-", "-o", "shows/ep.flac:-C 8", "-R", "00:01-00:02",
+		"--", "-m", "not-a-path",
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("rebaseOptions() = %q, want %q", args, want)
+	}
+}
+
+func TestParseBatchCSV(t *testing.T) {
+	data := "# jobs\nInput, output ,options,segments\n" +
+		"talk.wav,ep1.mp3,\"-E 300, --fade-in 500\",ep1.txt\n" +
+		"shows/*.flac,ep2.mp3\n"
+	jobs, err := parseBatchCSV([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BatchJob{
+		{Input: "talk.wav", Segments: "ep1.txt", Output: "ep1.mp3", Options: "-E 300, --fade-in 500"},
+		{Input: "shows/*.flac", Output: "ep2.mp3"},
+	}
+	if !reflect.DeepEqual(jobs, want) {
+		t.Errorf("parseBatchCSV() = %+v, want %+v", jobs, want)
+	}
+
+	if _, err := parseBatchCSV([]byte("input,output,speed\na.wav,b.mp3,2\n")); err == nil {
+		t.Error("parseBatchCSV() with an unknown column succeeded, want an error")
+	}
+	if jobs, err := parseBatchCSV(nil); err != nil || jobs != nil {
+		t.Errorf("parseBatchCSV() of nothing = %+v, %v, want no jobs", jobs, err)
+	}
+}
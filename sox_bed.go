@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Speech detection and ducking of the music bed.
+const (
+	duckFrame     = 50 * time.Millisecond
+	duckThreshold = -40.0                  // dBFS above which a frame holds speech
+	duckRamp      = 300 * time.Millisecond // to duck fully, or recover
+)
+
+// Mixing of two files at unity gain: the inputs are mixed at half volume,
+// so that their sum does not clip, and the limiter after the mix makes the
+// 6 dB up again, holding the peaks under the ceiling.
+const (
+	mixVolume  = "0.5"
+	mixCeiling = -1.0 // dBFS
+)
+
+// ..........................................................................
+// mixBed loops or trims the music bed to the duration of the clip, fades it
+// in and out and mixes it under the clip at the bed gain, ducked by duck dB
+// wherever there is speech. It returns the mixed file.
+func mixBed(clipPath, bedPath string, bedGain, duck float64, fade time.Duration, tempDir string) (string, error) {
+	format, err := getAudioFormat(clipPath)
+	if err != nil {
+		return "", fmt.Errorf("could not get format of '%s': %v", clipPath, err)
+	}
+	duration, err := getAudioDuration(clipPath)
+	if err != nil {
+		return "", fmt.Errorf("could not get duration of '%s': %v", clipPath, err)
+	}
+	bedDuration, err := getAudioDuration(bedPath)
+	if err != nil {
+		return "", fmt.Errorf("could not get duration of '%s': %v", bedPath, err)
+	}
+	if bedDuration <= 0 {
+		return "", fmt.Errorf("music bed '%s' is empty", bedPath)
+	}
+	slog.Info("Mixing the music bed under the speech", "bed", bedPath, "gain_db", bedGain, "duck_db", duck)
+
+	// Loop and trim the bed to the clip, in its sample format.
+	repeats := int(math.Ceil(duration.Seconds()/bedDuration.Seconds())) - 1
+	fade = min(fade, duration/2)
+	formatArgs := []string{"-r", strconv.Itoa(format.Rate), "-c", strconv.Itoa(format.Channels),
+		"-b", strconv.Itoa(wavBits(format.Bits))}
+	bedArgs := []string{"repeat", strconv.Itoa(max(repeats, 0)),
+		"trim", "0", fmt.Sprintf("%f", duration.Seconds()),
+		"gain", fmt.Sprintf("%.2f", bedGain),
+		"fade", "t", fmt.Sprintf("%f", fade.Seconds()), fmt.Sprintf("%f", duration.Seconds()),
+		fmt.Sprintf("%f", fade.Seconds())}
+	loopedPath := filepath.Join(tempDir, "bed_looped.wav")
+	loopedPath, err = clipCache.cached(loopedPath, []string{bedPath}, append(formatArgs, bedArgs...), func() error {
+		soxArgs := append([]string{bedPath}, formatArgs...)
+		if output, err := runCommand("sox", append(append(soxArgs, loopedPath), bedArgs...)...); err != nil {
+			return fmt.Errorf("failed to loop the music bed: %v\nOutput: %s", err, string(output))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Duck the bed wherever there is speech.
+	duckedPath := filepath.Join(tempDir, "bed_ducked.wav")
+	duckedPath, err = clipCache.cached(duckedPath, []string{clipPath, loopedPath},
+		[]string{"duck", fmt.Sprintf("%.2f", duck)}, func() error {
+			gains, err := duckGains(clipPath, format, duck)
+			if err != nil {
+				return err
+			}
+			frameSize := duckFrame.Seconds() * float64(format.Rate)
+			var streamErr error
+			err = encodeSamples(duckedPath, format.Rate, format.Channels, formatArgs, nil,
+				func(emit func(frame []float64) error) error {
+					i := 0
+					err := streamSamples(loopedPath, format, func(frame []float64) {
+						if streamErr != nil {
+							return
+						}
+						gain := interpolate(gains, float64(i)/frameSize)
+						for c := range frame {
+							frame[c] *= gain
+						}
+						streamErr = emit(frame)
+						i++
+					})
+					if streamErr != nil {
+						return streamErr
+					}
+					return err
+				})
+			return err
+		})
+	if err != nil {
+		return "", err
+	}
+
+	// Mix the ducked bed under the clip, at unity gain for both, limited.
+	mixedPath := filepath.Join(tempDir, "bed_mixed.wav")
+	return clipCache.cached(mixedPath, []string{clipPath, duckedPath},
+		append([]string{"-m", "-v", mixVolume}, mixLimiter()...), func() error {
+			if output, err := mixFiles(clipPath, duckedPath, mixedPath); err != nil {
+				return fmt.Errorf("failed to mix the music bed: %v\nOutput: %s", err, string(output))
+			}
+			return nil
+		})
+}
+
+// mixFiles mixes the two files into outPath at unity gain, limiting the
+// peaks of their sum instead of clipping them.
+func mixFiles(first, second, outPath string) ([]byte, error) {
+	args := []string{"-m", "-v", mixVolume, first, "-v", mixVolume, second, outPath}
+	return runCommand("sox", append(args, mixLimiter()...)...)
+}
+
+// mixLimiter returns the limiter after a mix, making up for the volume of
+// its inputs.
+func mixLimiter() []string {
+	return limiterArgs(20*math.Log10(2), mixCeiling)
+}
+
+// duckGains returns the linear gain of the bed for each frame of the clip:
+// duck dB down where there is speech, ramping down ahead of it and back up
+// after it.
+func duckGains(clipPath string, format AudioFormat, duck float64) ([]float64, error) {
+	frameSize := int(duckFrame.Seconds() * float64(format.Rate))
+	var levels []float64
+	var sum float64
+	var n int
+	err := streamSamples(clipPath, format, func(frame []float64) {
+		for _, v := range frame {
+			sum += v * v / float64(len(frame))
+		}
+		if n++; n == frameSize {
+			levels = append(levels, 10*math.Log10(sum/float64(n)+1e-12))
+			sum, n = 0, 0
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return duckEnvelope(levels, duck), nil
+}
+
+// duckEnvelope returns the linear gain of the bed for the speech level in
+// dBFS of each frame.
+func duckEnvelope(levels []float64, duck float64) []float64 {
+	// Limit how fast the gain may change, both ways, so that the bed
+	// recovers after speech and is already ducked when it starts.
+	step := duck / float64(duckRamp/duckFrame)
+	gains := make([]float64, len(levels))
+	for i, level := range levels {
+		if level > duckThreshold {
+			gains[i] = -duck
+		}
+		if i > 0 {
+			gains[i] = min(gains[i], gains[i-1]+step)
+		}
+	}
+	for i := len(gains) - 2; i >= 0; i-- {
+		gains[i] = min(gains[i], gains[i+1]+step)
+	}
+	for i, gain := range gains {
+		gains[i] = math.Pow(10, gain/20)
+	}
+	return gains
+}
+
+// interpolate returns the value at the fractional index x of the values,
+// holding the ends.
+func interpolate(values []float64, x float64) float64 {
+	if len(values) == 0 {
+		return 1
+	}
+	i := int(x)
+	if i >= len(values)-1 {
+		return values[len(values)-1]
+	}
+	frac := x - float64(i)
+	return values[i]*(1-frac) + values[i+1]*frac
+}
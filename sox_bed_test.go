@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDuckEnvelope(t *testing.T) {
+	// 6 frames make up the 300 ms ramp, so 12 dB of ducking moves 2 dB a
+	// frame, around speech in frames 8-11 of 20.
+	levels := make([]float64, 20)
+	for i := range levels {
+		levels[i] = silenceDB
+		if i >= 8 && i < 12 {
+			levels[i] = -20
+		}
+	}
+	wantDB := []float64{
+		0, 0, 0, -2, -4, -6, -8, -10,
+		-12, -12, -12, -12,
+		-10, -8, -6, -4, -2, 0, 0, 0,
+	}
+	gains := duckEnvelope(levels, 12)
+	if len(gains) != len(wantDB) {
+		t.Fatalf("duckEnvelope() returned %d gains, want %d", len(gains), len(wantDB))
+	}
+	for i, gain := range gains {
+		if got := 20 * math.Log10(gain); math.Abs(got-wantDB[i]) > 1e-9 {
+			t.Errorf("frame %d: gain %.2f dB, want %g dB", i, got, wantDB[i])
+		}
+	}
+
+	// Speech right at the start is ducked from the first frame, and a gap
+	// shorter than the ramps never recovers fully.
+	levels = []float64{-20, silenceDB, silenceDB, -20, silenceDB, silenceDB, silenceDB, silenceDB, silenceDB, silenceDB}
+	wantDB = []float64{-12, -10, -10, -12, -10, -8, -6, -4, -2, 0}
+	for i, gain := range duckEnvelope(levels, 12) {
+		if got := 20 * math.Log10(gain); math.Abs(got-wantDB[i]) > 1e-9 {
+			t.Errorf("short gap, frame %d: gain %.2f dB, want %g dB", i, got, wantDB[i])
+		}
+	}
+
+	// Without speech, or without ducking, the bed stays at unity.
+	for _, gain := range duckEnvelope([]float64{silenceDB, -50, silenceDB}, 12) {
+		if gain != 1 {
+			t.Errorf("no speech: gain %g, want 1", gain)
+		}
+	}
+	for _, gain := range duckEnvelope([]float64{-20, -10, -20}, 0) {
+		if gain != 1 {
+			t.Errorf("no ducking: gain %g, want 1", gain)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	values := []float64{0, 1, 0.5}
+	tests := []struct {
+		x, want float64
+	}{
+		{0, 0},
+		{0.25, 0.25},
+		{1, 1},
+		{1.5, 0.75},
+		{2, 0.5},
+		{7, 0.5},
+	}
+	for _, tt := range tests {
+		if got := interpolate(values, tt.x); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("interpolate(%g) = %g, want %g", tt.x, got, tt.want)
+		}
+	}
+	if got := interpolate(nil, 3); got != 1 {
+		t.Errorf("interpolate(nil) = %g, want 1", got)
+	}
+}
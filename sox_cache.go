@@ -0,0 +1,195 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Cache is the persistent, content-addressed store of intermediate files.
+// Each file is keyed by the hashes of its inputs, the parameters used to
+// make it and the sox version, so that unchanged clips and joint prefixes
+// are reused across runs. A nil *Cache disables caching.
+type Cache struct {
+	Dir     string
+	version string
+	hashes  map[string]string // file path -> content hash or cache key
+}
+
+// clipCache is the cache in use for the run, nil when disabled.
+var clipCache *Cache
+
+// ..........................................................................
+// openCache opens the cache in dir, creating it if needed.
+func openCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	output, err := runCommand("sox", "--version")
+	if err != nil {
+		return nil, fmt.Errorf("could not get sox version: %v\nOutput: %s", err, string(output))
+	}
+	return &Cache{
+		Dir:     dir,
+		version: strings.TrimSpace(string(output)),
+		hashes:  map[string]string{},
+	}, nil
+}
+
+// ..........................................................................
+// cached returns the cached file made from the inputs with the params. On a
+// miss, it calls create to make outPath, unless done by an earlier render in
+// the work directory, and stores the result in the cache.
+func (c *Cache) cached(outPath string, inputs, params []string, create func() error) (string, error) {
+	if c == nil {
+		return outPath, workDir.run(outPath, inputs, params, create)
+	}
+
+	key, err := c.key(inputs, params)
+	if err != nil {
+		return "", err
+	}
+	cachePath := filepath.Join(c.Dir, key[:2], key+filepath.Ext(outPath))
+	if _, err := os.Stat(cachePath); err == nil {
+		now := time.Now()
+		os.Chtimes(cachePath, now, now) // Mark as recently used for pruning
+		slog.Debug("Reusing cached file", "cached", cachePath, "for", filepath.Base(outPath))
+		c.hashes[cachePath] = key
+		return cachePath, nil
+	}
+
+	if err := workDir.run(outPath, inputs, params, create); err != nil {
+		return "", err
+	}
+	if err := copyFile(outPath, cachePath); err != nil {
+		return "", fmt.Errorf("could not store '%s' in cache: %v", outPath, err)
+	}
+	c.hashes[cachePath] = key
+	return cachePath, nil
+}
+
+// key computes the cache key for the inputs and params.
+func (c *Cache) key(inputs, params []string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n", c.version)
+	for _, input := range inputs {
+		hash, err := c.fileHash(input)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\n", hash)
+	}
+	fmt.Fprintf(h, "%q\n", params)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fileHash returns the content hash of the file, or its key if it comes
+// from the cache.
+func (c *Cache) fileHash(filePath string) (string, error) {
+	if hash, ok := c.hashes[filePath]; ok {
+		return hash, nil
+	}
+	hash, err := hashFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	c.hashes[filePath] = hash
+	return hash, nil
+}
+
+// hashFile returns the SHA-256 content hash of the file.
+func hashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("could not hash '%s': %v", filePath, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyFile copies src to dst, creating dst atomically.
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp(filepath.Dir(dst), ".tmp_*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	removeOnInterrupt(out.Name())
+	defer keepOnInterrupt(out.Name())
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), dst)
+}
+
+// ..........................................................................
+// pruneCache removes the cached files not used for maxAge, then the least
+// recently used ones until the cache is no larger than maxSize bytes.
+// A zero maxAge or maxSize disables that limit.
+func pruneCache(dir string, maxAge time.Duration, maxSize int64) error {
+	type cacheFile struct {
+		path  string
+		size  int64
+		mtime time.Time
+	}
+	var files []cacheFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, cacheFile{path, fi.Size(), fi.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Most recently used first.
+	sort.Slice(files, func(i, j int) bool { return files[i].mtime.After(files[j].mtime) })
+
+	var kept, removed, freed int64
+	for _, f := range files {
+		expired := maxAge > 0 && time.Since(f.mtime) > maxAge
+		oversize := maxSize > 0 && kept+f.size > maxSize
+		if !expired && !oversize {
+			kept += f.size
+			continue
+		}
+		if err := os.Remove(f.path); err != nil {
+			return err
+		}
+		removed++
+		freed += f.size
+	}
+	slog.Info("Pruned the cache", "removed", removed, "freed_mb", float64(freed)/1e6, "kept_mb", float64(kept)/1e6, "dir", dir)
+	return nil
+}
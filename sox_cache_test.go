@@ -0,0 +1,159 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// newTestCache returns a cache in a temp dir, with no sox version to get.
+func newTestCache(t *testing.T) *Cache {
+	t.Helper()
+	return &Cache{Dir: t.TempDir(), version: "sox: SoX v14.4.2", hashes: map[string]string{}}
+}
+
+func TestCacheKey(t *testing.T) {
+	a := writeFile(t, "a.wav", "first")
+	b := writeFile(t, "b.wav", "second")
+	same := writeFile(t, "same.wav", "first")
+	params := []string{"trim", "1.0", "2.0"}
+
+	c := newTestCache(t)
+	key := func(c *Cache, inputs, params []string) string {
+		t.Helper()
+		k, err := c.key(inputs, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	base := key(c, []string{a, b}, params)
+	if got := key(c, []string{a, b}, params); got != base {
+		t.Errorf("key of the same inputs and params changed: %s, then %s", base, got)
+	}
+	if got := key(c, []string{same, b}, params); got != base {
+		t.Error("key depends on the input paths, not their content")
+	}
+
+	other := newTestCache(t)
+	other.version = "sox: SoX v14.4.3"
+	for name, got := range map[string]string{
+		"input order": key(c, []string{b, a}, params),
+		"input count": key(c, []string{a}, params),
+		"params":      key(c, []string{a, b}, []string{"trim", "1.0", "2.5"}),
+		"param split": key(c, []string{a, b}, []string{"trim", "1.0 2.0"}),
+		"sox version": key(other, []string{a, b}, params),
+	} {
+		if got == base {
+			t.Errorf("key does not change with the %s", name)
+		}
+	}
+
+	if _, err := c.key([]string{filepath.Join(t.TempDir(), "missing.wav")}, nil); err == nil {
+		t.Error("key of a missing input succeeded, want an error")
+	}
+}
+
+func TestCacheCached(t *testing.T) {
+	c := newTestCache(t)
+	input := writeFile(t, "in.wav", "input")
+	outPath := filepath.Join(t.TempDir(), "clip.wav")
+	made := 0
+	creator := func(path string) func() error {
+		return func() error {
+			made++
+			return os.WriteFile(path, []byte("clip"), 0644)
+		}
+	}
+	create := creator(outPath)
+
+	first, err := c.cached(outPath, []string{input}, []string{"gain", "1"}, create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.cached(outPath, []string{input}, []string{"gain", "1"}, create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if made != 1 || first != second {
+		t.Errorf("made %d times, cached as %s then %s, want made once and reused", made, first, second)
+	}
+	if data, err := os.ReadFile(first); err != nil || string(data) != "clip" {
+		t.Errorf("cached file = %q, %v, want \"clip\"", data, err)
+	}
+
+	// Keyed by the cached input, without hashing it again.
+	if _, err := c.cached(outPath+".next", []string{first}, nil, creator(outPath+".next")); err != nil {
+		t.Fatal(err)
+	}
+	if made != 2 {
+		t.Errorf("made %d times, want 2", made)
+	}
+}
+
+func TestPruneCache(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	files := []struct {
+		name string
+		size int
+		age  time.Duration
+	}{
+		{"ab/new.wav", 100, time.Minute},
+		{"ab/recent.wav", 100, time.Hour},
+		{"cd/older.wav", 100, 2 * time.Hour},
+		{"cd/old.wav", 100, 48 * time.Hour},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, f.size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := now.Add(-f.age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	remaining := func() []string {
+		var names []string
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				name, _ := filepath.Rel(dir, path)
+				names = append(names, filepath.ToSlash(name))
+			}
+			return nil
+		})
+		sort.Strings(names)
+		return names
+	}
+
+	// Nothing to prune without limits.
+	if err := pruneCache(dir, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := remaining(); len(got) != 4 {
+		t.Errorf("pruneCache() with no limits left %q, want all 4", got)
+	}
+
+	// The expired ones go first.
+	if err := pruneCache(dir, 24*time.Hour, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := remaining(), []string{"ab/new.wav", "ab/recent.wav", "cd/older.wav"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pruneCache() by age left %q, want %q", got, want)
+	}
+
+	// Then the least recently used, down to the size.
+	if err := pruneCache(dir, 0, 250); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := remaining(), []string{"ab/new.wav", "ab/recent.wav"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pruneCache() by size left %q, want %q", got, want)
+	}
+}
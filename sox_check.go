@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// JointCheck holds the analysis of a single joint in the rendered output.
+type JointCheck struct {
+	Clip      int
+	Position  time.Duration
+	LevelJump float64 // dB between the audio before and after the cross-fade
+	Spectral  float64 // dB of log-spectral distance, regardless of level
+	Clipped   int     // clipped samples in the cross-fade
+	Dip       float64 // dB the cross-fade drops below the surrounding audio
+	Score     float64
+}
+
+//==========================================================================
+// Main entrance
+
+// ..........................................................................
+// soxcheck inspects each joint of the rendered output for audible splices.
+func soxcheck() {
+	x := checkJointsCommand
+
+	// Dependency Check: Ensure sox is installed.
+	if !commandExists("sox") {
+		log.Fatal("SoX not found in PATH. Please install it to continue.")
+	}
+
+	mapPath := x.MapFile
+	if mapPath == "" {
+		mapPath = timelinePath(x.Rendered)
+	}
+	timeline, err := readTimeline(mapPath)
+	if err != nil {
+		log.Fatalf("Error reading timeline map '%s' (render with --timeline to get one): %v", mapPath, err)
+	}
+	format, err := getAudioFormat(x.Rendered)
+	if err != nil {
+		log.Fatalf("Could not get format of '%s': %v", x.Rendered, err)
+	}
+	slog.Info("Checking joints", "joints", len(timeline.Joints), "file", x.Rendered)
+
+	window := time.Duration(x.Window) * time.Millisecond
+	suspicious := 0
+	fmt.Printf("%5s %12s %8s %8s %7s %7s %6s\n",
+		"Clip", "Position", "Jump dB", "Spec dB", "Clips", "Dip dB", "Score")
+	for _, tj := range timeline.Joints {
+		if tj.Gap > 0 {
+			slog.Debug("Skipping joint, joined with a gap", "clip", tj.Clip, "gap", tj.Gap)
+			continue
+		}
+		if tj.Position < timeline.FadeIn || (timeline.FadeOut > 0 && tj.Position > timeline.Duration-timeline.FadeOut) {
+			slog.Debug("Skipping joint, within the head or tail fade", "clip", tj.Clip)
+			continue
+		}
+		check, err := checkJoint(x.Rendered, format, tj.Joint(), window)
+		if err != nil {
+			log.Fatalf("Failed to check joint of clip %d: %v", tj.Clip, err)
+		}
+		check.Clip = tj.Clip
+		flag := ""
+		if check.Score >= x.Threshold {
+			flag = "  <- suspicious"
+			suspicious++
+		}
+		fmt.Printf("%5d %12s %8.1f %8.1f %7d %7.1f %6.2f%s\n", check.Clip,
+			check.Position.Round(time.Millisecond), check.LevelJump, check.Spectral,
+			check.Clipped, check.Dip, check.Score, flag)
+	}
+	slog.Info("Joints checked", "suspicious", suspicious, "joints", len(timeline.Joints))
+}
+
+//==========================================================================
+// Support functions
+
+// ..........................................................................
+// checkJoint compares the audio in the window before the cross-fade around
+// the joint with the audio in the window after it, and inspects the
+// cross-fade itself, combining the findings into a score where 1 or more is
+// likely audible.
+func checkJoint(filePath string, format AudioFormat, joint Joint, window time.Duration) (JointCheck, error) {
+	check := JointCheck{Position: joint.Position}
+
+	// The splice effect may move the cross-fade within the leeway.
+	fadeHalf := joint.Excess + joint.Leeway
+	start := max(joint.Position-fadeHalf-window, 0)
+	channels, err := readSamples(filePath, format, start, joint.Position+fadeHalf+window-start)
+	if err != nil {
+		return check, err
+	}
+	toIndex := func(d time.Duration) int {
+		return min(max(int((d-start).Seconds()*float64(format.Rate)), 0), len(channels[0]))
+	}
+	fadeStart, fadeEnd := toIndex(joint.Position-fadeHalf), toIndex(joint.Position+fadeHalf)
+	mono := mixdown(channels)
+	before, fade, after := mono[:fadeStart], mono[fadeStart:fadeEnd], mono[fadeEnd:]
+
+	// Sudden level jump.
+	beforeDB, afterDB := rmsDB(before), rmsDB(after)
+	check.LevelJump = math.Abs(beforeDB - afterDB)
+
+	// Spectral mismatch, of the level-normalised band spectra.
+	const bands = 24
+	beforeSpec := bandSpectrum(before, format.Rate, bands)
+	afterSpec := bandSpectrum(after, format.Rate, bands)
+	if beforeSpec != nil && afterSpec != nil {
+		var sum float64
+		var n int
+		for b := range beforeSpec {
+			if beforeSpec[b] > 1e-9 && afterSpec[b] > 1e-9 {
+				d := 10 * math.Log10(beforeSpec[b]/afterSpec[b])
+				sum += d * d
+				n++
+			}
+		}
+		if n > 0 {
+			check.Spectral = math.Sqrt(sum / float64(n))
+		}
+	}
+
+	// Clipped samples in the cross-fade, on any channel.
+	for _, samples := range channels {
+		for _, v := range samples[fadeStart:fadeEnd] {
+			if math.Abs(v) >= 0.999 {
+				check.Clipped++
+			}
+		}
+	}
+
+	// Phase cancellation dip: the cross-fade of two alike signals should
+	// hold about the level of the quieter side.
+	expected := min(beforeDB, afterDB)
+	frameSize := format.Rate / 50 // 20 ms
+	if expected > -50 && frameSize > 0 {
+		lowest := math.Inf(1)
+		for i := 0; i+frameSize <= len(fade); i += frameSize / 2 {
+			lowest = min(lowest, rmsDB(fade[i:i+frameSize]))
+		}
+		if !math.IsInf(lowest, 1) {
+			check.Dip = max(expected-lowest, 0)
+		}
+	}
+
+	check.Score = max(check.LevelJump/6, check.Spectral/6, check.Dip/10)
+	if check.Clipped > 0 {
+		check.Score += 1
+	}
+	return check, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/go-easygen/go-flags"
+	"gopkg.in/yaml.v3"
+)
+
+// configOrigins holds where the environment value of each option, keyed by
+// its environment variable, came from.
+var configOrigins = map[string]string{}
+
+// configFiles returns the layered config files, from the lowest precedence
+// to the highest: system, user and project.
+func configFiles() []string {
+	files := []string{"/etc/soxcut/config.yaml"}
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".config", "soxcut", "config.yaml"))
+	}
+	return append(files, ".soxcut.yaml")
+}
+
+// ..........................................................................
+// loadConfig reads the layered config files, keyed by the long option names,
+// e.g. "excess: 300", and passes their values on as the environment defaults
+// of the options. The precedence is thus, from the highest: flags, the
+// environment, the project, user and system config files, and the built-in
+// defaults.
+func loadConfig(parser *flags.Parser) error {
+	options := map[string][]*flags.Option{}
+	for _, option := range parserOptions(parser) {
+		options[option.LongName] = append(options[option.LongName], option)
+		if key := option.EnvDefaultKey; key != "" {
+			if _, ok := os.LookupEnv(key); ok {
+				configOrigins[key] = "env $" + key
+			}
+		}
+	}
+
+	// Later layers override the earlier ones, the environment all of them.
+	values := map[string]string{}
+	for _, path := range configFiles() {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var config map[string]interface{}
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return fmt.Errorf("invalid config file '%s': %w", path, err)
+		}
+		for name, value := range config {
+			if len(options[name]) == 0 {
+				return fmt.Errorf("config file '%s': unknown option '%s'", path, name)
+			}
+			for _, option := range options[name] {
+				key := option.EnvDefaultKey
+				if key == "" {
+					return fmt.Errorf("config file '%s': option '%s' cannot be configured", path, name)
+				}
+				if strings.HasPrefix(configOrigins[key], "env ") {
+					continue
+				}
+				if values[key], err = configValue(value, option.EnvDefaultDelim); err != nil {
+					return fmt.Errorf("config file '%s': option '%s': %v", path, name, err)
+				}
+				configOrigins[key] = "config " + path
+			}
+		}
+	}
+	for key, value := range values {
+		os.Setenv(key, value)
+	}
+	return nil
+}
+
+// configValue returns the config value as an environment value, lists
+// joined by the delimiter of the option.
+func configValue(value interface{}, delim string) (string, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return fmt.Sprint(value), nil
+	}
+	if delim == "" && len(list) > 1 {
+		return "", fmt.Errorf("takes a single value")
+	}
+	items := make([]string, len(list))
+	for i, item := range list {
+		items[i] = fmt.Sprint(item)
+	}
+	return strings.Join(items, delim), nil
+}
+
+// parserOptions returns the options of the parser and all its commands.
+func parserOptions(parser *flags.Parser) []*flags.Option {
+	options := groupOptions(parser.Group)
+	for _, command := range parser.Commands() {
+		options = append(options, groupOptions(command.Group)...)
+	}
+	return options
+}
+
+// groupOptions returns the options of the group and its sub-groups.
+func groupOptions(group *flags.Group) []*flags.Option {
+	options := group.Options()
+	for _, sub := range group.Groups() {
+		options = append(options, groupOptions(sub)...)
+	}
+	return options
+}
+
+// ..........................................................................
+// showConfig prints the effective value of each global option and option of
+// the command, and where it came from.
+func showConfig(command *flags.Command) {
+	for _, option := range commandOptions(command) {
+		origin := "default"
+		switch {
+		case option.IsSet() && !option.IsSetDefault():
+			origin = "flag"
+		case configOrigins[option.EnvDefaultKey] != "":
+			origin = configOrigins[option.EnvDefaultKey]
+		}
+		fmt.Printf("%-18s %-24s %s\n", option.LongName, optionValue(option), origin)
+	}
+}
+
+// commandOptions returns the named global options and options of the command,
+// if any, leaving out the ones calling a function.
+func commandOptions(command *flags.Command) []*flags.Option {
+	groups := []*flags.Group{gfParser.Group}
+	if command != nil {
+		groups = append(groups, command.Group)
+	}
+	var options []*flags.Option
+	for _, group := range groups {
+		for _, option := range groupOptions(group) {
+			if option.LongName != "" && option.Field().Type.Kind() != reflect.Func {
+				options = append(options, option)
+			}
+		}
+	}
+	return options
+}
+
+// optionValue returns the effective value of the option, lists joined by
+// commas.
+func optionValue(option *flags.Option) string {
+	if list, ok := option.Value().([]string); ok {
+		return strings.Join(list, ",")
+	}
+	return fmt.Sprint(option.Value())
+}
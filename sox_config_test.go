@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Options of different commands sharing a long name share its config key,
+// so they have to mean the same.
+func TestConfigKeysShared(t *testing.T) {
+	seen := map[string]string{}
+	for _, option := range parserOptions(gfParser) {
+		if option.LongName == "" || option.EnvDefaultKey == "" {
+			continue
+		}
+		want := fmt.Sprintf("%s %s %q required=%v", option.EnvDefaultKey, option.Field().Type,
+			option.Description, option.Required)
+		if got, ok := seen[option.LongName]; ok && got != want {
+			t.Errorf("option '%s' is both %s and %s", option.LongName, got, want)
+		}
+		seen[option.LongName] = want
+	}
+}
+
+func TestLoadConfigTimeline(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Setenv("HOME", dir)
+	for _, key := range []string{"SOXCUT_TIMELINE", "SOXCUT_MAPFILE"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Cleanup(func() { configOrigins = map[string]string{} })
+	if err := os.WriteFile(filepath.Join(dir, ".soxcut.yaml"), []byte("timeline: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loadConfig(gfParser); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("SOXCUT_TIMELINE"); got != "true" {
+		t.Errorf("SOXCUT_TIMELINE = %q, want \"true\"", got)
+	}
+	if got, ok := os.LookupEnv("SOXCUT_MAPFILE"); ok {
+		t.Errorf("SOXCUT_MAPFILE = %q, want it unset", got)
+	}
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -25,8 +28,7 @@ var (
 	// Format per line: HH:MM:SS.mmm HH:MM:SS.mmm (e.g., 00:01:10 00:01:15.6)
 	timingsFile = "test/segments.txt"
 
-	inputFile  string
-	outputFile string
+	inputFile string
 )
 
 // ========================== END OF CONFIGURATION ==============================
@@ -35,6 +37,20 @@ var (
 type ClipTiming struct {
 	Start time.Duration
 	End   time.Duration
+	Gap   Gap // inserted after the clip
+}
+
+// Joint holds the cross-fade parameters for the joint between two clips,
+// and the position and offset it is spliced at.
+type Joint struct {
+	Excess   time.Duration
+	Leeway   time.Duration
+	Position time.Duration
+	Offset   time.Duration // from the ideal splice point, within the leeway
+	Forced   bool          // whether the offset is forced instead of searched
+	Gap      Gap           // inserted in place of the cross-fade, if any
+	Stinger  string        // the intro or outro mixed in instead of spliced, if any
+	Overlap  time.Duration // of the mixed stinger
 }
 
 //==========================================================================
@@ -45,113 +61,352 @@ func soxcut(args []string) {
 
 	inputFile = extractCommand.FileI
 	timingsFile = extractCommand.FileS
+	if inputFile == "" || timingsFile == "" {
+		log.Fatal("The input and segments files are required.")
+	}
+	setDurations()
+	checkOptions()
+	setPreset()
+	setStingers(extractCommand.Intro, extractCommand.Outro, extractCommand.Overlap)
 
 	// Dependency Check: Ensure sox is installed.
 	if !commandExists("sox") {
 		log.Fatal("SoX not found in PATH. Please install it to continue.")
 	}
 	//log.Println("Found SoX executable.")
+	openClipCache()
+	startProgress(Opts.Progress)
+	startReport(Opts.Report, "extract", args)
 
-	log.Println("Audio Extracter started")
-	// Read and parse the clip timings file.
-	timings, err := parseTimingsFile(timingsFile)
+	slog.Info("Audio Extracter started")
+	tempDir := makeTempDir()
+	defer removeTempDir(tempDir)
+
+	preparedClipPaths, joints := extractClips(tempDir)
+	soxsplice(args, preparedClipPaths, joints, tempDir)
+}
+
+// ..........................................................................
+// soxsplice splices the prepared clips and encodes the result. joints holds
+// the parameters for each joint, nil to use the default ones for all.
+func soxsplice(args, preparedClipPaths []string, joints []Joint, tempDir string) {
+	fmtOpt, soxOptions := applyPreset(args)
+	outputs, err := parseOutputs(Opts.FileO, fmtOpt)
 	if err != nil {
-		log.Fatalf("Error reading timings file '%s': %v", timingsFile, err)
+		log.Fatalf("Failed to parse the outputs: %v", err)
 	}
-	if len(timings) == 0 {
-		log.Fatal("No clip timings found in the file. Exiting.")
+	for _, output := range outputs {
+		if err := checkClobber(output.Path); err != nil {
+			log.Fatalf("Refusing to render: %v", err)
+		}
+	}
+	for _, input := range []string{introFile, outroFile, Opts.Bed} {
+		report.Input(input)
 	}
-	log.Printf("Found %d clip(s) to process from '%s'.", len(timings), timingsFile)
 
-	// Create a temporary directory for intermediate files.
-	tempDir, err := os.MkdirTemp("", "sc_*")
+	slog.Info("Splicer started", "excess", excessDuration, "leeway", leewayDuration)
+
+	// Splice the given clips together.
+	preparedClipPaths = levelClips(preparedClipPaths, tempDir)
+	joints, err = forceOffsets(joints, len(preparedClipPaths), Opts.ForceOffset)
 	if err != nil {
-		log.Fatalf("Failed to create temporary directory: %v", err)
+		log.Fatalf("Failed to force offsets: %v", err)
 	}
-	defer os.RemoveAll(tempDir)
-	log.Printf("Temporary directory created at: %s", tempDir)
-
-	// Extract and prepare all clips for splicing.
-	preparedClipPaths, err := prepareClips(timings, tempDir)
+	preparedClipPaths, joints, err = attachStingers(preparedClipPaths, joints, tempDir)
 	if err != nil {
-		log.Fatalf("Failed during clip preparation: %v", err)
+		log.Fatalf("Failed to attach the intro and outro: %v", err)
 	}
-	log.Println("All clips extracted and prepared successfully.")
-	soxsplice(args, preparedClipPaths, tempDir)
-}
+	finalClipPath, joints, err := spliceClips(preparedClipPaths, joints, tempDir)
+	if err != nil {
+		log.Fatalf("Failed during splicing: %v", err)
+	}
+	slog.Info("All clips spliced successfully")
 
-// ..........................................................................
-func soxsplice(args, preparedClipPaths []string, tempDir string) {
-	excessDuration = time.Duration(Opts.DurExcess) * time.Millisecond
-	leewayDuration = time.Duration(Opts.DurLeeway) * time.Millisecond
-	outputFile = Opts.FileO
-	var soxOptions []string = args
+	// Pad the head and tail with room tone, if asked for.
+	if Opts.Pad != "" {
+		head, tail, err := parsePad(Opts.Pad)
+		if err != nil {
+			log.Fatalf("Failed to parse the padding: %v", err)
+		}
+		finalClipPath, err = padClip(finalClipPath, head, tail, tempDir)
+		if err != nil {
+			log.Fatalf("Failed during padding: %v", err)
+		}
+		for i := range joints {
+			joints[i].Position += head
+		}
+	}
 
-	log.Printf("Splicer started with excess: %v, leeway: %v\n",
-		excessDuration, leewayDuration)
+	// Mix the music bed under the speech, if asked for.
+	if Opts.Bed != "" {
+		finalClipPath, err = mixBed(finalClipPath, Opts.Bed, Opts.BedGain, Opts.Duck,
+			time.Duration(Opts.BedFade)*time.Millisecond, tempDir)
+		if err != nil {
+			log.Fatalf("Failed during music bed mixing: %v", err)
+		}
+	}
 
-	// Splice the given clips together.
-	finalClipPath, err := spliceClips(preparedClipPaths, tempDir)
+	// Fade the head and tail of the output, if asked for.
+	fade := cliFade()
+	if fade.In > 0 || fade.Out > 0 {
+		finalClipPath, err = fadeClip(finalClipPath, fade, tempDir)
+		if err != nil {
+			log.Fatalf("Failed during fading: %v", err)
+		}
+	}
+	duration, err := getAudioDuration(finalClipPath)
 	if err != nil {
-		log.Fatalf("Failed during splicing: %v", err)
+		log.Fatalf("Could not get duration of '%s': %v", finalClipPath, err)
+	}
+
+	// Measure and normalise the loudness in two passes, if asked for.
+	var loudnessReport *LoudnessReport
+	if Opts.LoudnessTarget != 0 {
+		finalClipPath, soxOptions, loudnessReport, err = normaliseLoudness(finalClipPath,
+			soxOptions, Opts.LoudnessTarget, Opts.TruePeak, tempDir)
+		if err != nil {
+			log.Fatalf("Failed during loudness measurement: %v", err)
+		}
 	}
-	log.Println("All clips spliced successfully.")
 
-	// Perform final encode to the output file, with user options.
-	//   sox <input> <output> <options>
-	finalCmdArgs := []string{finalClipPath}
-	foptArgs := strings.Fields(Opts.FmtOpt)
-	finalCmdArgs = append(finalCmdArgs, foptArgs...)
-	finalCmdArgs = append(finalCmdArgs, outputFile)
-	finalCmdArgs = append(finalCmdArgs, soxOptions...)
-	log.Printf("Encoding final file with\n\t\t '%v'...", finalCmdArgs)
+	// Perform final encode to the output files, with user options.
+	if err := encodeOutputs([]string{finalClipPath}, outputs, soxOptions); err != nil {
+		log.Fatalf("Failed to execute final sox command: %v", err)
+	}
+	for _, output := range outputs {
+		if !isFileOutput(output.Path) {
+			continue
+		}
+		if Opts.Timeline {
+			if err := writeTimeline(output.Path, joints, duration, fade); err != nil {
+				slog.Warn("Failed to write the timeline map", "err", err)
+			}
+		}
+		if loudnessReport != nil {
+			outputLoudness := *loudnessReport
+			if err := writeLoudnessReport(&outputLoudness, output.Path); err != nil {
+				slog.Warn("Failed to write the loudness report", "err", err)
+			}
+		}
+	}
 
-	cmd := exec.Command("sox", finalCmdArgs...)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		log.Fatalf("Failed to execute final sox command: %v\nOutput: %s", err, string(output))
+	if err := report.Write(Opts.Report, joints, outputs); err != nil {
+		slog.Warn("Failed to write the report", "err", err)
+	} else if report != nil {
+		slog.Info("Report saved", "path", Opts.Report)
 	}
 
-	log.Println("-----------------------------------")
-	log.Printf("Processing complete! Final audio saved to: %s", outputFile)
+	progress.Finish()
+	for _, output := range outputs {
+		slog.Info("Processing complete, final audio saved", "output", output.Path)
+	}
 }
 
 // ..........................................................................
 func soxjoin(args []string) {
 
 	inputFile = spliceCommand.FileList
+	if inputFile == "" {
+		inputFile = spliceCommand.Dir
+	}
+	setDurations()
+	checkOptions()
+	setPreset()
+	setStingers(spliceCommand.Intro, spliceCommand.Outro, spliceCommand.Overlap)
 
 	// Dependency Check: Ensure sox is installed.
 	if !commandExists("sox") {
 		log.Fatal("SoX not found in PATH. Please install it to continue.")
 	}
+	openClipCache()
+	startProgress(Opts.Progress)
+	startReport(Opts.Report, "splice", args)
+
+	slog.Info("Audio Splicer started")
+	tempDir := makeTempDir()
+	defer removeTempDir(tempDir)
+
+	target := AudioFormat{Rate: spliceCommand.Rate,
+		Channels: spliceCommand.Channels, Bits: spliceCommand.Bits}
+	clipPaths, joints := joinClips(spliceCommand.FileList, spliceCommand.Dir, target, tempDir)
+	soxsplice(args, clipPaths, joints, tempDir)
+}
 
-	log.Println("Audio Splicer started")
-	// Read and parse the list file.
-	clipPaths, err := parseListFile(inputFile)
+// ..........................................................................
+// extractClips reads the timings file and extracts the clips from the input
+// file, ready for splicing, returning them with the parameters of their joints.
+func extractClips(tempDir string) ([]string, []Joint) {
+	// Read and parse the clip timings file.
+	timings, err := parseTimingsFile(timingsFile)
 	if err != nil {
-		log.Fatalf("Error reading list file '%s': %v", inputFile, err)
+		log.Fatalf("Error reading timings file '%s': %v", timingsFile, err)
+	}
+	if len(timings) == 0 {
+		log.Fatal("No clip timings found in the file. Exiting.")
+	}
+	slog.Info("Found clips to process", "clips", len(timings), "file", timingsFile)
+	roomToneSource = inputFile
+	report.Input(inputFile)
+	for i, timing := range timings {
+		report.Segment(i+1, inputFile, timing.Start, timing.End)
 	}
 
-	// Create a temporary directory for intermediate files.
-	tempDir, err := os.MkdirTemp("", "sc_*")
+	// Move the boundaries to better cut points, if asked for.
+	if Opts.Snap != "" && Opts.Snap != snapNone {
+		timings, err = snapTimings(timings, Opts.Snap,
+			time.Duration(Opts.SnapWindow)*time.Millisecond)
+		if err != nil {
+			log.Fatalf("Failed during boundary snapping: %v", err)
+		}
+	}
+
+	// Fill the regions removed between the snapped segments, if asked for.
+	if Opts.KeepTiming {
+		silent := 0
+		for i := range timings[:len(timings)-1] {
+			if removed := timings[i+1].Start - timings[i].End; timings[i].Gap.Duration == 0 && removed > 0 {
+				timings[i].Gap.Duration = removed
+				if timings[i].Gap.Tone == nil && Opts.RoomTone == "" {
+					silent++
+				}
+			}
+		}
+		if silent > 0 {
+			slog.Warn("No room tone set, filling the removed regions with silence", "regions", silent)
+		}
+	}
+
+	// Extract and prepare all clips for splicing.
+	preparedClipPaths, err := prepareClips(timings, tempDir)
 	if err != nil {
-		log.Fatalf("Failed to create temporary directory: %v", err)
+		log.Fatalf("Failed during clip preparation: %v", err)
+	}
+	slog.Info("All clips extracted and prepared successfully")
+
+	var joints []Joint
+	for _, timing := range timings[:len(timings)-1] {
+		joints = append(joints, Joint{Excess: excessDuration, Leeway: leewayDuration, Gap: timing.Gap})
+	}
+	return preparedClipPaths, joints
+}
+
+// ..........................................................................
+// joinClips reads the sources to splice and prepares them in the target
+// format, returning the clips together with the parameters of their joints.
+func joinClips(listFile, dir string, target AudioFormat, tempDir string) ([]string, []Joint) {
+	// Read and parse the list file, playlist or directory.
+	entries, err := readSources(listFile, dir)
+	if err != nil {
+		log.Fatalf("Error reading sources from '%s': %v", inputFile, err)
+	}
+	if len(entries) == 0 {
+		log.Fatalf("No sources found in '%s'. Exiting.", inputFile)
+	}
+	slog.Info("Found sources to splice", "sources", len(entries), "file", inputFile)
+	roomToneSource = entries[0].Path
+
+	// Trim, adjust and pad the list entries that ask for it.
+	clipPaths, joints, err := prepareListClips(entries, tempDir)
+	if err != nil {
+		log.Fatalf("Failed during clip preparation: %v", err)
 	}
-	defer os.RemoveAll(tempDir)
-	log.Printf("Temporary directory created at: %s", tempDir)
 
-	soxsplice(args, clipPaths, tempDir)
+	// Bring all clips to the same sample format for the splice effect.
+	clipPaths, err = harmoniseClips(clipPaths, target, tempDir)
+	if err != nil {
+		log.Fatalf("Failed during format harmonisation: %v", err)
+	}
+	return clipPaths, joints
 }
 
 //==========================================================================
 // Support functions
 
+// makeTempDir creates a temporary directory for intermediate files, or opens
+// the work directory, if asked for.
+func makeTempDir() string {
+	if Opts.WorkDir != "" {
+		var err error
+		if workDir, err = openWorkDir(Opts.WorkDir, Opts.Resume); err != nil {
+			log.Fatalf("Failed to open the work directory '%s': %v", Opts.WorkDir, err)
+		}
+		return Opts.WorkDir
+	}
+	if Opts.Resume {
+		log.Fatal("Resuming needs the work directory of the render to resume.")
+	}
+	tempDir, err := os.MkdirTemp("", "sc_*")
+	if err != nil {
+		log.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	slog.Debug("Temporary directory created", "dir", tempDir)
+	removeOnInterrupt(tempDir)
+	return tempDir
+}
+
+// removeTempDir removes the temporary directory, but keeps the work directory.
+func removeTempDir(tempDir string) {
+	if workDir == nil {
+		os.RemoveAll(tempDir)
+	}
+}
+
+// levelClips matches the loudness of the clips, if asked for.
+func levelClips(clipPaths []string, tempDir string) []string {
+	if !Opts.MatchLoudness {
+		return clipPaths
+	}
+	clipPaths, err := matchClipLoudness(clipPaths, Opts.MaxBoost, tempDir)
+	if err != nil {
+		log.Fatalf("Failed during loudness matching: %v", err)
+	}
+	return clipPaths
+}
+
+// setDurations sets the default joint durations from the cli options.
+func setDurations() {
+	excessDuration = time.Duration(Opts.DurExcess) * time.Millisecond
+	leewayDuration = time.Duration(Opts.DurLeeway) * time.Millisecond
+}
+
+// checkOptions rejects the global options out of range or clashing, before
+// any work.
+func checkOptions() {
+	if Opts.EnvelopeWeight < 0 || Opts.EnvelopeWeight > 1 {
+		log.Fatalf("Invalid envelope weight %g, expected a value from 0 to 1.", Opts.EnvelopeWeight)
+	}
+	// The JSON progress events go to stdout, and would corrupt the audio.
+	if Opts.Progress == progressJSON {
+		outputs, _ := parseOutputs(Opts.FileO, "")
+		for _, output := range outputs {
+			if output.Path == "-" {
+				log.Fatal("Cannot output to stdout '-' with the JSON progress on it.")
+			}
+		}
+	}
+}
+
+// openClipCache opens the cache of intermediate files, if asked for.
+func openClipCache() {
+	if Opts.CacheDir == "" {
+		return
+	}
+	var err error
+	clipCache, err = openCache(Opts.CacheDir)
+	if err != nil {
+		log.Fatalf("Failed to open cache directory '%s': %v", Opts.CacheDir, err)
+	}
+	slog.Info("Using cache directory", "dir", Opts.CacheDir)
+}
+
 // ..........................................................................
 // prepareClips loops through the timings, trimming each clip from the source
-// with the correct excess/leeway for perfect splicing.
+// with the correct excess/leeway for perfect splicing. Clip boundaries at a
+// gap are kept as they are, and faded instead.
 func prepareClips(timings []ClipTiming, tempDir string) ([]string, error) {
 	var preparedClipPaths []string
 	clipCount := len(timings)
+	progress.Phase("trim", clipCount)
 
 	for i, timing := range timings {
 		if timing.Start >= timing.End {
@@ -162,41 +417,50 @@ func prepareClips(timings []ClipTiming, tempDir string) ([]string, error) {
 		var trimStart, trimDuration time.Duration
 		clipPath := filepath.Join(tempDir, fmt.Sprintf("clip_%d_prep.wav", i))
 
-		// Determine trim parameters based on clip position (first, middle, last).
+		// Determine trim parameters based on clip position (first, middle,
+		// last) and the joints on either side of it.
 		isFirst := (i == 0)
 		isLast := (i == clipCount-1)
-
-		switch {
-		case isFirst && isLast: // Only one clip
-			trimStart = timing.Start
-			trimDuration = idealDuration
-		case isFirst: // First clip of many
-			trimStart = timing.Start
-			trimDuration = idealDuration + excessDuration
-		case isLast: // Last clip of many
-			trimStart = timing.Start - (excessDuration + leewayDuration)
-			trimDuration = idealDuration + excessDuration + leewayDuration
-		default: // A middle clip
-			trimStart = timing.Start - (excessDuration + leewayDuration)
-			trimDuration = idealDuration + excessDuration + leewayDuration + excessDuration
+		gapBefore := !isFirst && timings[i-1].Gap.Duration > 0
+		gapAfter := timing.Gap.Duration > 0 // Trailing, after the last clip
+
+		trimStart = timing.Start
+		trimDuration = idealDuration
+		if !isFirst && !gapBefore { // Leeway and excess before the joint
+			trimStart -= excessDuration + leewayDuration
+			trimDuration += excessDuration + leewayDuration
+		}
+		if !isLast && !gapAfter { // Excess after the joint
+			trimDuration += excessDuration
 		}
 
 		if trimStart < 0 {
-			log.Printf("Warning: Clip %d start time is too early for full leeway. Trimming from 0.", i+1)
+			slog.Warn("Clip start time is too early for full leeway, trimming from 0", "clip", i+1)
 			trimDuration += trimStart // Adjust duration since we start later.
 			trimStart = 0
 		}
 
-		log.Printf(" -> Preparing clip %d: trimming from %v for %.3fs (%.3fs)",
-			i+1, trimStart, idealDuration.Seconds(), trimDuration.Seconds())
+		slog.Info("Preparing clip", "clip", i+1, "start", trimStart, "duration", idealDuration, "trimmed", trimDuration)
 
-		cmd := exec.Command("sox", inputFile, clipPath, "trim",
+		trimArgs := []string{"trim",
 			fmt.Sprintf("%f", trimStart.Seconds()),
 			fmt.Sprintf("%f", trimDuration.Seconds()),
-		)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			return nil, fmt.Errorf("failed to trim clip %d: %v\nOutput: %s", i+1, err, string(output))
 		}
+		trimArgs = append(trimArgs, gapFadeArgs(gapBefore, gapAfter)...)
+		report.Trim(i+1, trimStart, trimStart+trimDuration)
+		clipPath, err := clipCache.cached(clipPath, []string{inputFile}, trimArgs, func() error {
+			if output, err := runCommand("sox", append([]string{inputFile, clipPath}, trimArgs...)...); err != nil {
+				return fmt.Errorf("failed to trim clip %d: %v\nOutput: %s", i+1, err, string(output))
+			}
+			return nil
+		})
+		if err == nil && isLast && gapAfter {
+			clipPath, err = appendGap(clipPath, timing.Gap, filepath.Join(tempDir, fmt.Sprintf("clip_%d_gap.wav", i)))
+		}
+		if err != nil {
+			return nil, err
+		}
+		progress.Step(i+1, 0, clipPath)
 		preparedClipPaths = append(preparedClipPaths, clipPath)
 	}
 	return preparedClipPaths, nil
@@ -204,42 +468,146 @@ func prepareClips(timings []ClipTiming, tempDir string) ([]string, error) {
 
 // ..........................................................................
 // spliceClips iteratively joins the prepared clips using the splice effect.
-func spliceClips(clipPaths []string, tempDir string) (string, error) {
+// joints[i-1] holds the parameters of the joint before clipPaths[i]; missing
+// ones fall back to the default excess/leeway durations. It returns the
+// spliced file and the joints with their positions in it.
+func spliceClips(clipPaths []string, joints []Joint, tempDir string) (string, []Joint, error) {
 	if len(clipPaths) <= 1 {
-		return clipPaths[0], nil // Only one clip, no splicing needed.
+		return clipPaths[0], nil, nil // Only one clip, no splicing needed.
 	}
+	var spliced []Joint
+	progress.Phase("splice", len(clipPaths)-1)
 
 	currentCombinedFile := clipPaths[0]
 
+	// Number the clips as given, leaving out the intro.
+	first := 1
+	if len(joints) > 0 && joints[0].Stinger == stingerIntro {
+		first = 0
+	}
 	for i := 1; i < len(clipPaths); i++ {
-		nextClip := clipPaths[i]
 		tempOutputFile := filepath.Join(tempDir, fmt.Sprintf("combined_%d.wav", i))
+		joint := jointAt(joints, i-1)
+		combinedFile, err := spliceJoint(currentCombinedFile, clipPaths[i],
+			&joint, tempOutputFile, i+first)
+		if err != nil {
+			return "", nil, err
+		}
+		spliced = append(spliced, joint)
+		progress.Step(i+1, i, combinedFile)
+		currentCombinedFile = combinedFile
+	}
+	return currentCombinedFile, spliced, nil
+}
 
-		// Get the duration of the current combined file to determine the splice position.
-		// Per the man page, this is the duration of the first input file to the splice command.
-		splicePos, err := getAudioDuration(currentCombinedFile)
+// ..........................................................................
+// spliceJoint splices clip n onto the end of the combined file into outPath,
+// returning the resulting file. The joint position is recorded in joint.
+func spliceJoint(combinedFile, nextClip string, joint *Joint, outPath string, n int) (string, error) {
+	// Get the duration of the current combined file to determine the splice position.
+	// Per the man page, this is the duration of the first input file to the splice command.
+	splicePos, err := getAudioDuration(combinedFile)
+	if err != nil {
+		return "", fmt.Errorf("could not get duration of '%s': %v", combinedFile, err)
+	}
+	joint.Position = splicePos
+	if joint.Gap.Duration > 0 {
+		return gapJoint(combinedFile, nextClip, joint, outPath, n)
+	}
+	if joint.Stinger != "" {
+		return mixJoint(combinedFile, nextClip, joint, outPath)
+	}
+
+	// Search the best splice point natively, leaving none to the splice effect.
+	leeway := joint.Leeway
+	if (Opts.Search == searchNative && joint.Leeway > 0) || joint.Forced {
+		nextClip, err = alignNextClip(combinedFile, nextClip, joint, splicePos, outPath)
 		if err != nil {
-			return "", fmt.Errorf("could not get duration of '%s': %v", currentCombinedFile, err)
+			return "", fmt.Errorf("failed to align clip %d: %v", n, err)
 		}
+		leeway = 0
+		slog.Debug("Aligned clip", "clip", n, "offset", joint.Offset, "forced", joint.Forced)
+	}
+
+	slog.Info("Splicing clip", "clip", n, "position", splicePos)
+	spliceArgs := fmt.Sprintf("%f,%f,%f", splicePos.Seconds(), joint.Excess.Seconds(), leeway.Seconds())
+
+	// Keyed by both inputs, so that unchanged joint prefixes are reused.
+	return clipCache.cached(outPath, []string{combinedFile, nextClip},
+		[]string{"splice", "-q", spliceArgs}, func() error {
+			if output, err := runCommand("sox", combinedFile, nextClip, outPath, "splice", "-q", spliceArgs); err != nil {
+				return fmt.Errorf("failed to splice clip %d: %v\nOutput: %s", n, err, string(output))
+			}
+			return nil
+		})
+}
+
+// jointAt returns the parameters of joint i, falling back to the default
+// excess/leeway durations.
+func jointAt(joints []Joint, i int) Joint {
+	if i < len(joints) {
+		return joints[i]
+	}
+	return Joint{Excess: excessDuration, Leeway: leewayDuration}
+}
+
+// ..........................................................................
+// encodeOutput encodes the inputs, in order, into the output file with its
+// format options and the user effects. A file output is encoded next to the
+// file and moved into place only once complete, never over an existing one
+// with --no-clobber.
+func encodeOutput(inputs []string, output Output, effects []string) error {
+	if err := checkClobber(output.Path); err != nil {
+		return err
+	}
+	partial := output.Path
+	if isFileOutput(output.Path) {
+		partial = partialPath(output.Path)
+	}
 
-		fmt.Printf(" -> Splicing clip %d at joint point: %v\n", i+1, splicePos)
-		spliceArgs := fmt.Sprintf("%f,%f,%f", splicePos.Seconds(), excessDuration.Seconds(), leewayDuration.Seconds())
+	//   sox <inputs> <fopts> <output> <effects>
+	cmdArgs := append([]string{}, inputs...)
+	cmdArgs = append(cmdArgs, strings.Fields(output.FmtOpt)...)
+	cmdArgs = append(cmdArgs, partial)
+	cmdArgs = append(cmdArgs, effects...)
+	slog.Info("Encoding final file", "output", output.Path)
 
-		cmd := exec.Command("sox", currentCombinedFile, nextClip, tempOutputFile, "splice", "-q", spliceArgs)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			return "", fmt.Errorf("failed to splice clip %d: %v\nOutput: %s", i+1, err, string(output))
+	if partial == output.Path {
+		if output, err := runCommand("sox", cmdArgs...); err != nil {
+			return fmt.Errorf("%v\nOutput: %s", err, string(output))
 		}
-		currentCombinedFile = tempOutputFile
+		return nil
+	}
+
+	removeOnInterrupt(partial)
+	defer keepOnInterrupt(partial)
+	if output, err := runCommand("sox", cmdArgs...); err != nil {
+		os.Remove(partial)
+		return fmt.Errorf("%v\nOutput: %s", err, string(output))
+	}
+	if Opts.NoClobber {
+		// Linking fails if the output exists by now, where renaming would
+		// replace it.
+		defer os.Remove(partial)
+		if err := os.Link(partial, output.Path); errors.Is(err, fs.ErrExist) {
+			return clobberError(output.Path)
+		} else if err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := os.Rename(partial, output.Path); err != nil {
+		os.Remove(partial)
+		return err
 	}
-	return currentCombinedFile, nil
+	return nil
 }
 
 // --- Helper Functions ---
 
 // getAudioDuration uses `soxi` to get the precise duration of an audio file.
 func getAudioDuration(filePath string) (time.Duration, error) {
-	cmd := exec.Command("soxi", "-D", filePath)
-	output, err := cmd.CombinedOutput()
+	output, err := runCommand("soxi", "-D", filePath)
 	if err != nil {
 		return 0, fmt.Errorf("soxi command failed: %w: %s", err, string(output))
 	}
@@ -252,7 +620,15 @@ func getAudioDuration(filePath string) (time.Duration, error) {
 }
 
 // ..........................................................................
-// parseTimingsFile reads the HH:MM:SS.mmm formatted file.
+// parseTimingsFile reads the HH:MM:SS.mmm formatted file. The start and end
+// time on each line may be followed by space-separated key=value fields:
+//
+//	gap=ms                   silence to insert after the clip
+//	tone=START-END           region of the input to fill the gap with room tone
+//
+// A gap after the last clip trails it, filled as the others, as after the
+// last list entry.
+// A tone needs a gap, unless the removed regions are kept as the gaps.
 func parseTimingsFile(filePath string) ([]ClipTiming, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -271,8 +647,8 @@ func parseTimingsFile(filePath string) ([]ClipTiming, error) {
 		}
 
 		parts := strings.Fields(line)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("line %d: expected 2 fields (start and end time), got %d", lineNumber, len(parts))
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("line %d: expected at least 2 fields (start and end time), got %d", lineNumber, len(parts))
 		}
 
 		start, err := parseISOTime(parts[0])
@@ -283,33 +659,31 @@ func parseTimingsFile(filePath string) ([]ClipTiming, error) {
 		if err != nil {
 			return nil, fmt.Errorf("line %d: invalid end time format '%s': %w", lineNumber, parts[1], err)
 		}
-		timings = append(timings, ClipTiming{Start: start, End: end})
-	}
-
-	return timings, scanner.Err()
-}
-
-// ..........................................................................
-//
-//	parseListFile reads the audio list file.
-func parseListFile(filePath string) ([]string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") { // Skip empty lines and comments
-			continue
+		timing := ClipTiming{Start: start, End: end, Gap: Gap{Source: inputFile}}
+		for _, field := range parts[2:] {
+			key, value, ok := strings.Cut(field, "=")
+			switch {
+			case !ok:
+				err = fmt.Errorf("expected key=value")
+			case key == "gap":
+				timing.Gap.Duration, err = parseMilliseconds(value)
+			case key == "tone":
+				timing.Gap.Tone, err = parseRegion(value)
+			default:
+				err = fmt.Errorf("unknown field")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid field '%s': %w", lineNumber, field, err)
+			}
 		}
-		lines = append(lines, line)
+		// The removed region is the gap when keeping the timing.
+		if timing.Gap.Tone != nil && timing.Gap.Duration == 0 && !Opts.KeepTiming {
+			return nil, fmt.Errorf("line %d: room tone given without a gap", lineNumber)
+		}
+		timings = append(timings, timing)
 	}
 
-	return lines, scanner.Err()
+	return timings, scanner.Err()
 }
 
 var durationFormat = []string{"", "05", "04:05", "15:04:05"}
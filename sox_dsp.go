@@ -0,0 +1,109 @@
+package main
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// silenceDB is the level below which audio counts as silence.
+const silenceDB = -90.0
+
+// mixdown averages the channels into a single one.
+func mixdown(channels [][]float64) []float64 {
+	if len(channels) == 1 {
+		return channels[0]
+	}
+	mono := make([]float64, len(channels[0]))
+	for _, samples := range channels {
+		for i, v := range samples {
+			mono[i] += v / float64(len(channels))
+		}
+	}
+	return mono
+}
+
+// rmsDB returns the RMS level of the samples in dBFS, no lower than silenceDB.
+func rmsDB(samples []float64) float64 {
+	if len(samples) == 0 {
+		return silenceDB
+	}
+	var sum float64
+	for _, v := range samples {
+		sum += v * v
+	}
+	return max(10*math.Log10(sum/float64(len(samples))), silenceDB)
+}
+
+// fft computes the discrete Fourier transform of x in place; len(x) must be
+// a power of 2.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ { // Bit reversal permutation
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			wk := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], wk*x[start+k+size/2]
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				wk *= w
+			}
+		}
+	}
+}
+
+// bandSpectrum returns the average power of the samples in log-spaced
+// frequency bands between 50 Hz and 16 kHz (or Nyquist), normalised to sum
+// to 1 so that only the spectral shape remains. It is nil for silence.
+func bandSpectrum(samples []float64, rate, bands int) []float64 {
+	const frameSize = 2048
+	lo, hi := 50.0, math.Min(16000, float64(rate)/2)
+	power := make([]float64, bands)
+	frame := make([]complex128, frameSize)
+	for start := 0; start+frameSize <= len(samples); start += frameSize / 2 {
+		for i := range frame { // Hann window
+			w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/frameSize)
+			frame[i] = complex(samples[start+i]*w, 0)
+		}
+		fft(frame)
+		for k := 1; k < frameSize/2; k++ {
+			f := float64(k) * float64(rate) / frameSize
+			if f < lo || f >= hi {
+				continue
+			}
+			band := int(float64(bands) * math.Log(f/lo) / math.Log(hi/lo))
+			power[band] += real(frame[k])*real(frame[k]) + imag(frame[k])*imag(frame[k])
+		}
+	}
+
+	var total float64
+	for _, p := range power {
+		total += p
+	}
+	if total == 0 {
+		return nil
+	}
+	for i := range power {
+		power[i] /= total
+	}
+	return power
+}
+
+// rmsEnvelope returns the RMS level in dBFS of each frame of frameSize
+// samples, moving hop samples at a time.
+func rmsEnvelope(samples []float64, frameSize, hop int) []float64 {
+	var envelope []float64
+	for i := 0; i+frameSize <= len(samples); i += hop {
+		envelope = append(envelope, rmsDB(samples[i:i+frameSize]))
+	}
+	return envelope
+}
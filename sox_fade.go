@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// fadeCurves maps the fade curve names to the sox fade types.
+var fadeCurves = map[string]string{
+	"linear":       "t",
+	"quarter-sine": "q",
+	"half-sine":    "h",
+	"logarithmic":  "l",
+	"parabola":     "p",
+}
+
+// Fade is the fade-in at the head and fade-out at the tail of the output.
+type Fade struct {
+	In    time.Duration
+	Out   time.Duration
+	Curve string
+}
+
+// cliFade returns the fade set by the cli.
+func cliFade() Fade {
+	return Fade{
+		In:    time.Duration(Opts.FadeIn) * time.Millisecond,
+		Out:   time.Duration(Opts.FadeOut) * time.Millisecond,
+		Curve: Opts.FadeCurve,
+	}
+}
+
+// ..........................................................................
+// fadeClip fades the head and tail of the clip in and out with the fade
+// curve, returning the resulting file.
+func fadeClip(clipPath string, fade Fade, tempDir string) (string, error) {
+	curve, ok := fadeCurves[fade.Curve]
+	if !ok {
+		return "", fmt.Errorf("unknown fade curve '%s'", fade.Curve)
+	}
+	duration, err := getAudioDuration(clipPath)
+	if err != nil {
+		return "", fmt.Errorf("could not get duration of '%s': %v", clipPath, err)
+	}
+	if fade.In+fade.Out > duration {
+		return "", fmt.Errorf("fades of %v and %v are longer than the output of %v", fade.In, fade.Out, duration)
+	}
+
+	slog.Info("Fading in and out", "in", fade.In, "out", fade.Out, "curve", fade.Curve)
+	fadedPath := filepath.Join(tempDir, "faded.wav")
+	fadeArgs := []string{"fade", curve, fmt.Sprintf("%f", fade.In.Seconds()), "-0", fmt.Sprintf("%f", fade.Out.Seconds())}
+	return clipCache.cached(fadedPath, []string{clipPath}, fadeArgs, func() error {
+		if output, err := runCommand("sox", append([]string{clipPath, fadedPath}, fadeArgs...)...); err != nil {
+			return fmt.Errorf("%v\nOutput: %s", err, string(output))
+		}
+		return nil
+	})
+}
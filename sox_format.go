@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// AudioFormat holds the sample format of an audio file. Zero fields of a
+// target format are left for harmoniseClips to choose.
+type AudioFormat struct {
+	Rate     int
+	Channels int
+	Bits     int
+}
+
+func (f AudioFormat) String() string {
+	return fmt.Sprintf("%d Hz, %d ch, %d bit", f.Rate, f.Channels, f.Bits)
+}
+
+// ..........................................................................
+// getAudioFormat uses `soxi` to get the sample format of an audio file.
+func getAudioFormat(filePath string) (AudioFormat, error) {
+	var format AudioFormat
+	output, err := runCommand("soxi", filePath)
+	if err != nil {
+		return format, fmt.Errorf("soxi command failed: %w: %s", err, string(output))
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "Sample Rate":
+			format.Rate, err = strconv.Atoi(value)
+		case "Channels":
+			format.Channels, err = strconv.Atoi(value)
+		case "Precision":
+			format.Bits, err = strconv.Atoi(strings.TrimSuffix(value, "-bit"))
+		}
+		if err != nil {
+			return format, fmt.Errorf("could not parse soxi %s '%s': %w", strings.TrimSpace(key), value, err)
+		}
+	}
+	if format.Rate == 0 || format.Channels == 0 {
+		return format, fmt.Errorf("could not find the sample format in soxi output: %s", string(output))
+	}
+	return format, scanner.Err()
+}
+
+// ..........................................................................
+// harmoniseClips probes every clip and converts the ones not matching the
+// target format into tempDir, as the splice effect needs them all alike.
+// Zero target fields are set to the highest among the clips.
+func harmoniseClips(clipPaths []string, target AudioFormat, tempDir string) ([]string, error) {
+	formats := make([]AudioFormat, len(clipPaths))
+	highest := AudioFormat{}
+	for i, clipPath := range clipPaths {
+		format, err := getAudioFormat(clipPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not get format of '%s': %v", clipPath, err)
+		}
+		formats[i] = format
+		highest.Rate = max(highest.Rate, format.Rate)
+		highest.Channels = max(highest.Channels, format.Channels)
+		highest.Bits = max(highest.Bits, format.Bits)
+	}
+	if target.Rate == 0 {
+		target.Rate = highest.Rate
+	}
+	if target.Channels == 0 {
+		target.Channels = highest.Channels
+	}
+	if target.Bits == 0 {
+		target.Bits = highest.Bits
+	}
+	target.Bits = wavBits(target.Bits)
+	slog.Info("Target format for splicing", "format", target)
+
+	harmonised := make([]string, len(clipPaths))
+	converted := 0
+	for i, clipPath := range clipPaths {
+		format := formats[i]
+		if format.Rate == target.Rate && format.Channels == target.Channels &&
+			wavBits(format.Bits) == target.Bits {
+			harmonised[i] = clipPath
+			continue
+		}
+
+		convPath := filepath.Join(tempDir, fmt.Sprintf("clip_%d_conv.wav", i))
+		soxArgs := []string{"-b", strconv.Itoa(target.Bits)}
+		// Down-mix before resampling and up-mix after, to resample the fewest channels.
+		remix := remixArgs(format.Channels, target.Channels)
+		if format.Channels > target.Channels {
+			soxArgs = append(soxArgs, remix...)
+		}
+		if format.Rate != target.Rate {
+			soxArgs = append(soxArgs, "rate", "-v", strconv.Itoa(target.Rate))
+		}
+		if format.Channels < target.Channels {
+			soxArgs = append(soxArgs, remix...)
+		}
+
+		slog.Info("Converting clip", "clip", i+1, "file", clipPath, "from", format)
+		convPath, err := clipCache.cached(convPath, []string{clipPath}, soxArgs, func() error {
+			// sox <input> -b <bits> <output> [effects...]
+			cmdArgs := append([]string{clipPath}, soxArgs[:2]...)
+			cmdArgs = append(cmdArgs, convPath)
+			cmdArgs = append(cmdArgs, soxArgs[2:]...)
+			if output, err := runCommand("sox", cmdArgs...); err != nil {
+				return fmt.Errorf("failed to convert clip %d: %v\nOutput: %s", i+1, err, string(output))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		harmonised[i] = convPath
+		converted++
+	}
+	slog.Info("Converted clips", "converted", converted, "clips", len(clipPaths), "format", target)
+	return harmonised, nil
+}
+
+// remixArgs returns the effect changing the number of channels, if needed.
+func remixArgs(from, to int) []string {
+	switch {
+	case from == to:
+		return nil
+	case from == 1: // Duplicate mono onto every channel
+		args := []string{"remix"}
+		for i := 0; i < to; i++ {
+			args = append(args, "1")
+		}
+		return args
+	case to == 1: // Mix every channel down to mono
+		return []string{"remix", "-"}
+	}
+	return []string{"channels", strconv.Itoa(to)}
+}
+
+// wavBits rounds the bit depth up to one the intermediate WAV files support.
+func wavBits(bits int) int {
+	switch {
+	case bits <= 0: // Unknown precision
+		return 16
+	case bits <= 8:
+		return 8
+	case bits <= 16:
+		return 16
+	case bits <= 24:
+		return 24
+	}
+	return 32
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemixArgs(t *testing.T) {
+	tests := []struct {
+		from, to int
+		want     []string
+	}{
+		{2, 2, nil},
+		{1, 1, nil},
+		{1, 2, []string{"remix", "1", "1"}},
+		{1, 6, []string{"remix", "1", "1", "1", "1", "1", "1"}},
+		{2, 1, []string{"remix", "-"}},
+		{6, 1, []string{"remix", "-"}},
+		{6, 2, []string{"channels", "2"}},
+		{2, 4, []string{"channels", "4"}},
+	}
+	for _, tt := range tests {
+		if got := remixArgs(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("remixArgs(%d, %d) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestWavBits(t *testing.T) {
+	tests := []struct{ bits, want int }{
+		{0, 16}, {-1, 16}, {1, 8}, {8, 8}, {12, 16}, {16, 16}, {20, 24}, {24, 24}, {32, 32}, {64, 32},
+	}
+	for _, tt := range tests {
+		if got := wavBits(tt.bits); got != tt.want {
+			t.Errorf("wavBits(%d) = %d, want %d", tt.bits, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gapFade is the length of the fades into and out of an inserted gap.
+const gapFade = 20 * time.Millisecond
+
+// Gap is a pause inserted at a joint in place of the cross-fade: silence,
+// or room tone sampled from a region of a source.
+type Gap struct {
+	Duration time.Duration
+	Source   string      // source of the room tone
+	Tone     *ClipTiming // region of the source with the room tone, nil for the cli one
+}
+
+// parseRegion parses a START-END region of [[HH:]MM:]SS[.mmm] times.
+func parseRegion(s string) (*ClipTiming, error) {
+	start, end, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("expected START-END")
+	}
+	var region ClipTiming
+	var err error
+	if region.Start, err = parseISOTime(start); err != nil {
+		return nil, err
+	}
+	if region.End, err = parseISOTime(end); err != nil {
+		return nil, err
+	}
+	if region.Start >= region.End {
+		return nil, fmt.Errorf("start is not before end")
+	}
+	return &region, nil
+}
+
+// gapFadeArgs returns the fade effect for a clip with a gap before and/or
+// after it, nil for none.
+func gapFadeArgs(gapBefore, gapAfter bool) []string {
+	if !gapBefore && !gapAfter {
+		return nil
+	}
+	fadeIn, fadeOut := 0.0, 0.0
+	if gapBefore {
+		fadeIn = gapFade.Seconds()
+	}
+	if gapAfter {
+		fadeOut = gapFade.Seconds()
+	}
+	return []string{"fade", "h", fmt.Sprintf("%f", fadeIn), "-0", fmt.Sprintf("%f", fadeOut)}
+}
+
+// ..........................................................................
+// gapJoint appends the gap and then clip n onto the end of the combined file
+// into outPath, returning the resulting file. The clips on either side of a
+// gap are trimmed to their ideal boundaries and faded at preparation, so
+// they are simply concatenated.
+func gapJoint(combinedFile, nextClip string, joint *Joint, outPath string, n int) (string, error) {
+	format, err := getAudioFormat(nextClip)
+	if err != nil {
+		return "", fmt.Errorf("could not get format of '%s': %v", nextClip, err)
+	}
+	gapPath := strings.TrimSuffix(outPath, ".wav") + "_gap.wav"
+	gapPath, err = makeGap(joint.Gap, format, gapPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to make the gap before clip %d: %v", n, err)
+	}
+
+	slog.Info("Inserting gap", "clip", n, "gap", joint.Gap.Duration, "position", joint.Position)
+	return clipCache.cached(outPath, []string{combinedFile, gapPath, nextClip}, nil, func() error {
+		if output, err := runCommand("sox", combinedFile, gapPath, nextClip, outPath); err != nil {
+			return fmt.Errorf("failed to join clip %d: %v\nOutput: %s", n, err, string(output))
+		}
+		return nil
+	})
+}
+
+// appendGap appends the gap after the last clip, which no joint follows, to
+// its end into outPath, returning the resulting file.
+func appendGap(clipPath string, gap Gap, outPath string) (string, error) {
+	format, err := getAudioFormat(clipPath)
+	if err != nil {
+		return "", fmt.Errorf("could not get format of '%s': %v", clipPath, err)
+	}
+	gapPath := strings.TrimSuffix(outPath, ".wav") + "_tone.wav"
+	gapPath, err = makeGap(gap, format, gapPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to make the trailing gap: %v", err)
+	}
+	return clipCache.cached(outPath, []string{clipPath, gapPath}, nil, func() error {
+		if output, err := runCommand("sox", clipPath, gapPath, outPath); err != nil {
+			return fmt.Errorf("failed to add the trailing gap: %v\nOutput: %s", err, string(output))
+		}
+		return nil
+	})
+}
+
+// makeGap renders the gap in the given sample format into outPath: the room
+// tone looped to the gap duration and faded in and out, or silence. Gaps
+// without a room tone region of their own take the one set by the cli.
+func makeGap(gap Gap, format AudioFormat, outPath string) (string, error) {
+	if gap.Tone == nil && gap.Source != "" {
+		var err error
+		if gap.Tone, err = roomToneRegion(gap.Source); err != nil {
+			return "", err
+		}
+	}
+	formatArgs := []string{"-r", strconv.Itoa(format.Rate), "-c", strconv.Itoa(format.Channels),
+		"-b", strconv.Itoa(wavBits(format.Bits))}
+	duration := fmt.Sprintf("%f", gap.Duration.Seconds())
+
+	if gap.Tone != nil {
+		params := append(formatArgs, "tone", fmt.Sprintf("%f", gap.Tone.Start.Seconds()),
+			fmt.Sprintf("%f", gap.Tone.End.Seconds()), duration)
+		return clipCache.cached(outPath, []string{gap.Source}, params, func() error {
+			return loopRoomTone(gap.Source, *gap.Tone, gap.Duration, format, outPath)
+		})
+	}
+
+	params := append(formatArgs, "trim", "0", duration)
+	return clipCache.cached(outPath, nil, params, func() error {
+		soxArgs := append([]string{"-n"}, formatArgs...)
+		soxArgs = append(soxArgs, outPath)
+		if output, err := runCommand("sox", append(soxArgs, "trim", "0", duration)...); err != nil {
+			return fmt.Errorf("%v\nOutput: %s", err, string(output))
+		}
+		return nil
+	})
+}
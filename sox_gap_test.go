@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRegion(t *testing.T) {
+	tests := []struct {
+		s    string
+		want ClipTiming
+	}{
+		{"01-02.5", ClipTiming{Start: time.Second, End: 2500 * time.Millisecond}},
+		{"00:01.0-00:02.5", ClipTiming{Start: time.Second, End: 2500 * time.Millisecond}},
+		{"59-01:00:01", ClipTiming{Start: 59 * time.Second, End: time.Hour + time.Second}},
+	}
+	for _, tt := range tests {
+		got, err := parseRegion(tt.s)
+		if err != nil {
+			t.Errorf("parseRegion(%q): %v", tt.s, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("parseRegion(%q) = %+v, want %+v", tt.s, *got, tt.want)
+		}
+	}
+
+	for _, s := range []string{"", "1", "2-1", "1-1", "a-2", "1-b"} {
+		if _, err := parseRegion(s); err == nil {
+			t.Errorf("parseRegion(%q) succeeded, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ListEntry holds a single source from the splice list file, together with
+// its optional per-entry adjustments.
+type ListEntry struct {
+	Path  string
+	In    time.Duration // trim start point, 0 for the start of the file
+	Out   time.Duration // trim end point, 0 for the end of the file
+	Gain  float64       // gain in dB
+	Joint Joint         // cross-fade parameters or gap for the joint after this entry
+
+	jointFields []string // the fields given that only apply to a following joint
+}
+
+// needsPrep tells whether the entry has to be processed before splicing.
+func (e ListEntry) needsPrep() bool {
+	return e.In > 0 || e.Out > 0 || e.Gain != 0 || e.Joint.Gap.Duration > 0
+}
+
+// ..........................................................................
+// parseListFile reads the audio list file.
+// Each line holds a source path, optionally followed by a '|' and
+// space-separated key=value fields:
+//
+//	in=[[HH:]MM:]SS[.mmm]   trim start point
+//	out=[[HH:]MM:]SS[.mmm]  trim end point
+//	gain=dB                 gain to apply
+//	gap=ms                  silence to insert after the entry
+//	tone=START-END          region of the entry to fill the gap with room tone
+//	excess=ms               excess duration of the joint after the entry
+//	leeway=ms               leeway duration of the joint after the entry
+//	offset=ms               forced splice offset of the joint after the entry
+//
+// The joint fields are an error on the last entry, with no joint after it.
+func parseListFile(filePath string) ([]ListEntry, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []ListEntry
+	scanner := bufio.NewScanner(file)
+	lineNumber, lastLine := 0, 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") { // Skip empty lines and comments
+			continue
+		}
+		entry, err := parseListEntry(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		entries = append(entries, entry)
+		lastLine = lineNumber
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// No joint follows the last entry for its joint fields to apply to.
+	if n := len(entries); n > 0 && len(entries[n-1].jointFields) > 0 {
+		return nil, fmt.Errorf("line %d: %s given on the last entry, with no joint after it",
+			lastLine, strings.Join(entries[n-1].jointFields, ", "))
+	}
+	return entries, nil
+}
+
+// parseListEntry parses a single line of the list file.
+func parseListEntry(line string) (ListEntry, error) {
+	path, fields, _ := strings.Cut(line, "|")
+	entry := ListEntry{
+		Path:  strings.TrimSpace(path),
+		Joint: Joint{Excess: excessDuration, Leeway: leewayDuration},
+	}
+	entry.Joint.Gap.Source = entry.Path
+	if entry.Path == "" {
+		return entry, fmt.Errorf("missing source path")
+	}
+
+	for _, field := range strings.Fields(fields) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return entry, fmt.Errorf("invalid field '%s', expected key=value", field)
+		}
+		var err error
+		switch key {
+		case "in":
+			entry.In, err = parseISOTime(value)
+		case "out":
+			entry.Out, err = parseISOTime(value)
+		case "gain":
+			entry.Gain, err = strconv.ParseFloat(value, 64)
+		case "gap":
+			entry.Joint.Gap.Duration, err = parseMilliseconds(value)
+		case "tone":
+			entry.Joint.Gap.Tone, err = parseRegion(value)
+		case "excess":
+			entry.Joint.Excess, err = parseMilliseconds(value)
+			entry.jointFields = append(entry.jointFields, key)
+		case "leeway":
+			entry.Joint.Leeway, err = parseMilliseconds(value)
+			entry.jointFields = append(entry.jointFields, key)
+		case "offset":
+			entry.jointFields = append(entry.jointFields, key)
+			var ms float64
+			ms, err = strconv.ParseFloat(value, 64)
+			entry.Joint.Offset = time.Duration(ms * float64(time.Millisecond))
+			entry.Joint.Forced = true
+		default:
+			return entry, fmt.Errorf("unknown field '%s'", key)
+		}
+		if err != nil {
+			return entry, fmt.Errorf("invalid %s value '%s': %w", key, value, err)
+		}
+	}
+
+	if entry.Out > 0 && entry.In >= entry.Out {
+		return entry, fmt.Errorf("invalid trim for '%s': in point is not before out point", entry.Path)
+	}
+	if entry.Joint.Gap.Tone != nil && entry.Joint.Gap.Duration == 0 {
+		return entry, fmt.Errorf("room tone for '%s' given without a gap", entry.Path)
+	}
+	return entry, nil
+}
+
+// parseMilliseconds converts a non-negative number of ms to a time.Duration.
+func parseMilliseconds(s string) (time.Duration, error) {
+	ms, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if ms < 0 {
+		return 0, fmt.Errorf("negative duration")
+	}
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
+
+// ..........................................................................
+// prepareListClips trims, adjusts and pads the list entries that ask for it,
+// keeping the excess/leeway around each trimmed joint for perfect splicing.
+// Entries at a gap are faded into and out of it instead.
+// It returns the clips to splice and the parameters for each of their joints.
+func prepareListClips(entries []ListEntry, tempDir string) ([]string, []Joint, error) {
+	var clipPaths []string
+	var joints []Joint
+	entryCount := len(entries)
+	progress.Phase("trim", entryCount)
+
+	for i, entry := range entries {
+		report.Input(entry.Path)
+		report.Segment(i+1, entry.Path, entry.In, entry.Out)
+		isFirst := (i == 0)
+		isLast := (i == entryCount-1)
+		gapBefore := !isFirst && entries[i-1].Joint.Gap.Duration > 0
+		gapAfter := entry.Joint.Gap.Duration > 0 // Trailing, after the last entry
+		if !isFirst {
+			joints = append(joints, entries[i-1].Joint)
+		}
+		if !entry.needsPrep() && !gapBefore {
+			progress.Step(i+1, 0, "")
+			clipPaths = append(clipPaths, entry.Path)
+			continue
+		}
+
+		// Extend trimmed boundaries to cover the neighbouring joints.
+		trimStart := entry.In
+		if !isFirst && !gapBefore && entry.In > 0 {
+			prev := entries[i-1].Joint
+			trimStart -= prev.Excess + prev.Leeway
+			if trimStart < 0 {
+				slog.Warn("Entry in point is too early for full leeway, trimming from 0", "entry", i+1)
+				trimStart = 0
+			}
+		}
+		trimEnd := entry.Out
+		if !isLast && !gapAfter && entry.Out > 0 {
+			trimEnd += entry.Joint.Excess
+		}
+
+		clipPath := filepath.Join(tempDir, fmt.Sprintf("entry_%d_prep.wav", i))
+		soxArgs := []string{entry.Path, clipPath}
+		if trimStart > 0 || trimEnd > 0 {
+			soxArgs = append(soxArgs, "trim", fmt.Sprintf("%f", trimStart.Seconds()))
+			if trimEnd > 0 {
+				soxArgs = append(soxArgs, fmt.Sprintf("%f", (trimEnd-trimStart).Seconds()))
+			}
+			report.Trim(i+1, trimStart, trimEnd)
+		}
+		if entry.Gain != 0 {
+			soxArgs = append(soxArgs, "gain", fmt.Sprintf("%g", entry.Gain))
+		}
+		soxArgs = append(soxArgs, gapFadeArgs(gapBefore, gapAfter)...)
+
+		slog.Info("Preparing entry", "entry", i+1, "file", entry.Path, "args", soxArgs[2:])
+		clipPath, err := clipCache.cached(clipPath, []string{entry.Path}, soxArgs[2:], func() error {
+			if output, err := runCommand("sox", soxArgs...); err != nil {
+				return fmt.Errorf("failed to prepare entry %d: %v\nOutput: %s", i+1, err, string(output))
+			}
+			return nil
+		})
+		if err == nil && isLast && gapAfter {
+			clipPath, err = appendGap(clipPath, entry.Joint.Gap, filepath.Join(tempDir, fmt.Sprintf("entry_%d_gap.wav", i)))
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		progress.Step(i+1, 0, clipPath)
+		clipPaths = append(clipPaths, clipPath)
+	}
+	return clipPaths, joints, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes the content to the named file in a new temp dir,
+// returning its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseListEntry(t *testing.T) {
+	excess, leeway := excessDuration, leewayDuration // the defaults
+	tests := []struct {
+		line string
+		want ListEntry
+	}{
+		{"a.wav", ListEntry{Path: "a.wav", Joint: Joint{Excess: excess, Leeway: leeway}}},
+		{" a b.wav | ", ListEntry{Path: "a b.wav", Joint: Joint{Excess: excess, Leeway: leeway}}},
+		{"a.wav | in=00:12.5 out=03:40 gain=-2.5", ListEntry{Path: "a.wav",
+			In: 12500 * time.Millisecond, Out: 220 * time.Second, Gain: -2.5,
+			Joint: Joint{Excess: excess, Leeway: leeway}}},
+		{"a.wav | excess=300 leeway=100", ListEntry{Path: "a.wav",
+			Joint: Joint{Excess: 300 * time.Millisecond, Leeway: 100 * time.Millisecond}}},
+		{"a.wav | offset=-20", ListEntry{Path: "a.wav",
+			Joint: Joint{Excess: excess, Leeway: leeway, Offset: -20 * time.Millisecond, Forced: true}}},
+		{"a.wav | gap=500 tone=00:01.0-00:02.5", ListEntry{Path: "a.wav",
+			Joint: Joint{Excess: excess, Leeway: leeway, Gap: Gap{Duration: 500 * time.Millisecond,
+				Tone: &ClipTiming{Start: time.Second, End: 2500 * time.Millisecond}}}}},
+	}
+	for _, tt := range tests {
+		got, err := parseListEntry(tt.line)
+		if err != nil {
+			t.Errorf("parseListEntry(%q): %v", tt.line, err)
+			continue
+		}
+		want := tt.want
+		want.Joint.Gap.Source = want.Path
+		got.jointFields = nil
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parseListEntry(%q) = %+v, want %+v", tt.line, got, want)
+		}
+	}
+}
+
+func TestParseListEntryInvalid(t *testing.T) {
+	for _, line := range []string{
+		"| gain=1",
+		"a.wav | gain",
+		"a.wav | gain=loud",
+		"a.wav | gap=-5",
+		"a.wav | tone=00:02",
+		"a.wav | volume=2",
+		"a.wav | in=10 out=5",
+		"a.wav | tone=00:01-00:02",
+		"a.wav | gap=0 tone=00:01-00:02",
+	} {
+		if _, err := parseListEntry(line); err == nil {
+			t.Errorf("parseListEntry(%q) succeeded, want an error", line)
+		}
+	}
+}
+
+func TestParseListFileLastJoint(t *testing.T) {
+	path := writeFile(t, "show.lst", "a.wav | excess=300\n\nb.wav | leeway=100 gap=500\n# end\n")
+	_, err := parseListFile(path)
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3:") {
+		t.Errorf("parseListFile() = %v, want an error on line 3", err)
+	}
+
+	path = writeFile(t, "show.lst", "a.wav | excess=300\nb.wav | gap=500\n")
+	if _, err := parseListFile(path); err != nil {
+		t.Errorf("parseListFile(): %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Log formats.
+const (
+	logText = "text"
+	logJSON = "json"
+)
+
+// setupLogging logs to stderr in the given format, at info level, at debug
+// level with -v, and with the source locations as well with -vv. What is left
+// of the std log calls, the fatal errors, is logged at error level. The
+// records make way for the progress bar, if shown.
+func setupLogging(verbose int, format string) {
+	opts := &slog.HandlerOptions{Level: slog.LevelInfo}
+	if verbose > 0 {
+		opts.Level = slog.LevelDebug
+	}
+	if verbose > 1 {
+		opts.AddSource = true
+	}
+	var handler slog.Handler = slog.NewTextHandler(progressLog{}, opts)
+	if format == logJSON {
+		handler = slog.NewJSONHandler(progressLog{}, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+	log.SetOutput(slog.NewLogLogger(handler, slog.LevelError).Writer())
+}
+
+// ..........................................................................
+// runCommand runs the external command and returns its combined output,
+// logging the command line and its output at debug level.
+func runCommand(name string, args ...string) ([]byte, error) {
+	cmd := newCommand(name, args...)
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	start := logCommand(cmd)
+	err := startCommand(cmd)
+	if err == nil {
+		err = waitCommand(cmd)
+	}
+	logCommandDone(cmd, start, output.Bytes(), err)
+	return output.Bytes(), err
+}
+
+// logCommand logs the command line at debug level, returning the start time.
+func logCommand(cmd *exec.Cmd) time.Time {
+	slog.Debug("Running command", "cmd", commandLine(cmd.Args))
+	return time.Now()
+}
+
+// logCommandDone logs how long the command took and its output at debug
+// level, and records the command in the run report.
+func logCommandDone(cmd *exec.Cmd, start time.Time, output []byte, err error) {
+	elapsed := time.Since(start)
+	report.Ran(cmd.Args, elapsed, err)
+	attrs := []any{"cmd", cmd.Args[0], "elapsed", elapsed.Round(time.Millisecond)}
+	if err != nil {
+		attrs = append(attrs, "err", err)
+	}
+	if out := strings.TrimSpace(string(output)); out != "" {
+		attrs = append(attrs, "output", out)
+	}
+	slog.Debug("Command done", attrs...)
+}
+
+// commandLine returns the arguments as a shell command line, quoting the ones
+// that need it.
+func commandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$*?;&|<>()") {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"sort"
+)
+
+// biquad is a second order IIR filter, in direct form I.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting returns the two stage K-weighting filter of ITU-R BS.1770,
+// with the coefficients derived for the sample rate.
+func kWeighting(rate int) [2]biquad {
+	// Stage 1: high shelf modelling the acoustic effect of the head.
+	f0, gain, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / float64(rate))
+	vh := math.Pow(10, gain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	// Stage 2: RLB high pass.
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / float64(rate))
+	a0 = 1 + k/q + k*k
+	highpass := biquad{
+		b0: 1, b1: -2, b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return [2]biquad{shelf, highpass}
+}
+
+// LoudnessMeter measures the loudness of a stream of frames per
+// ITU-R BS.1770 / EBU R128, keeping the channel-weighted mean square of the
+// K-weighted signal for every 100 ms step.
+type LoudnessMeter struct {
+	filters  [][2]biquad
+	weights  []float64
+	stepSize int
+	sum      float64
+	count    int
+	steps    []float64
+
+	// True peak detection by oversampling.
+	history  [][]float64 // latest samples per channel, newest first
+	phases   [][]float64 // polyphase interpolation filter
+	truePeak float64
+}
+
+// truePeakTaps is the number of interpolation filter taps per phase.
+const truePeakTaps = 12
+
+// NewLoudnessMeter creates a meter for the sample format.
+func NewLoudnessMeter(format AudioFormat) *LoudnessMeter {
+	m := &LoudnessMeter{
+		filters:  make([][2]biquad, format.Channels),
+		weights:  make([]float64, format.Channels),
+		stepSize: format.Rate / 10,
+	}
+	for c := range m.filters {
+		m.filters[c] = kWeighting(format.Rate)
+		m.weights[c] = 1.0
+	}
+	if format.Channels == 6 { // 5.1: L R C LFE Ls Rs
+		m.weights[3], m.weights[4], m.weights[5] = 0, 1.41, 1.41
+	}
+
+	// Oversample to at least 192 kHz for the true peak.
+	factor := 1
+	for factor*format.Rate < 192000 && factor < 4 {
+		factor *= 2
+	}
+	m.phases = interpolationPhases(factor)
+	m.history = make([][]float64, format.Channels)
+	for c := range m.history {
+		m.history[c] = make([]float64, truePeakTaps)
+	}
+	return m
+}
+
+// interpolationPhases returns the phases of a Hann windowed sinc filter,
+// interpolating by the factor.
+func interpolationPhases(factor int) [][]float64 {
+	phases := make([][]float64, factor)
+	n := truePeakTaps * factor
+	center := float64(n-1) / 2
+	for p := range phases {
+		phases[p] = make([]float64, truePeakTaps)
+		var sum float64
+		for k := range phases[p] {
+			t := float64(k*factor+p) - center
+			h := 1.0
+			if t != 0 {
+				x := math.Pi * t / float64(factor)
+				h = math.Sin(x) / x
+			}
+			h *= 0.5 + 0.5*math.Cos(2*math.Pi*t/float64(n))
+			phases[p][k] = h
+			sum += h
+		}
+		for k := range phases[p] { // Unity gain for each phase
+			phases[p][k] /= sum
+		}
+	}
+	return phases
+}
+
+// Add feeds one frame, one sample per channel, to the meter.
+func (m *LoudnessMeter) Add(frame []float64) {
+	for c, x := range frame {
+		y := m.filters[c][1].process(m.filters[c][0].process(x))
+		m.sum += m.weights[c] * y * y
+
+		h := m.history[c]
+		copy(h[1:], h)
+		h[0] = x
+		for _, phase := range m.phases {
+			var v float64
+			for k, coef := range phase {
+				v += coef * h[k]
+			}
+			m.truePeak = max(m.truePeak, math.Abs(v))
+		}
+	}
+	m.count++
+	if m.count == m.stepSize {
+		m.steps = append(m.steps, m.sum/float64(m.count))
+		m.sum, m.count = 0, 0
+	}
+}
+
+// blockPowers returns the mean square of each window of n steps, moving
+// hop steps at a time.
+func (m *LoudnessMeter) blockPowers(n, hop int) []float64 {
+	var powers []float64
+	for i := 0; i+n <= len(m.steps); i += hop {
+		var sum float64
+		for _, p := range m.steps[i : i+n] {
+			sum += p
+		}
+		powers = append(powers, sum/float64(n))
+	}
+	return powers
+}
+
+// Integrated returns the gated integrated loudness in LUFS, -Inf if the
+// audio is too short or silent.
+func (m *LoudnessMeter) Integrated() float64 {
+	// 400 ms blocks with 75% overlap, gated at -70 LUFS then at -10 LU.
+	blocks := m.blockPowers(4, 1)
+	gated := gateBlocks(blocks, -70)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+	gated = gateBlocks(gated, powerLoudness(meanPower(gated))-10)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+	return powerLoudness(meanPower(gated))
+}
+
+// LoudnessRange returns the loudness range in LU per EBU Tech 3342, from
+// the distribution of the gated short-term loudness.
+func (m *LoudnessMeter) LoudnessRange() float64 {
+	// 3 s blocks every 100 ms, gated at -70 LUFS then at -20 LU.
+	blocks := gateBlocks(m.blockPowers(30, 1), -70)
+	if len(blocks) == 0 {
+		return 0
+	}
+	blocks = gateBlocks(blocks, powerLoudness(meanPower(blocks))-20)
+	if len(blocks) == 0 {
+		return 0
+	}
+	loudness := make([]float64, len(blocks))
+	for i, p := range blocks {
+		loudness[i] = powerLoudness(p)
+	}
+	sort.Float64s(loudness)
+	percentile := func(pc float64) float64 {
+		return loudness[int(math.Round(pc*float64(len(loudness)-1)))]
+	}
+	return percentile(0.95) - percentile(0.10)
+}
+
+// TruePeak returns the true peak level in dBTP.
+func (m *LoudnessMeter) TruePeak() float64 {
+	return 20 * math.Log10(m.truePeak)
+}
+
+// gateBlocks keeps the block powers whose loudness is above the gate.
+func gateBlocks(powers []float64, gate float64) []float64 {
+	var kept []float64
+	for _, p := range powers {
+		if powerLoudness(p) > gate {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+func meanPower(powers []float64) float64 {
+	var sum float64
+	for _, p := range powers {
+		sum += p
+	}
+	return sum / float64(len(powers))
+}
+
+// powerLoudness converts a channel-weighted mean square to LUFS.
+func powerLoudness(p float64) float64 {
+	return -0.691 + 10*math.Log10(p)
+}
+
+// ..........................................................................
+// measureLoudness returns the integrated loudness of the audio file in LUFS.
+func measureLoudness(filePath string) (float64, error) {
+	meter, err := meterFile(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return meter.Integrated(), nil
+}
+
+// meterFile runs the whole audio file through a loudness meter.
+func meterFile(filePath string) (*LoudnessMeter, error) {
+	format, err := getAudioFormat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not get format of '%s': %v", filePath, err)
+	}
+	meter := NewLoudnessMeter(format)
+	if err := streamSamples(filePath, format, meter.Add); err != nil {
+		return nil, err
+	}
+	return meter, nil
+}
+
+// ..........................................................................
+// matchClipLoudness brings every clip to the median integrated loudness of
+// all clips, boosting quiet ones by no more than maxBoost dB.
+func matchClipLoudness(clipPaths []string, maxBoost float64, tempDir string) ([]string, error) {
+	loudness := make([]float64, len(clipPaths))
+	var measured []float64
+	for i, clipPath := range clipPaths {
+		l, err := measureLoudness(clipPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not measure loudness of clip %d: %v", i+1, err)
+		}
+		loudness[i] = l
+		if !math.IsInf(l, -1) {
+			measured = append(measured, l)
+		}
+	}
+	if len(measured) == 0 {
+		slog.Warn("No clip loud enough to match the loudness of")
+		return clipPaths, nil
+	}
+	sort.Float64s(measured)
+	target := measured[len(measured)/2]
+	if len(measured)%2 == 0 {
+		target = (measured[len(measured)/2-1] + target) / 2
+	}
+	slog.Info("Matching clip loudness", "target_lufs", target, "max_boost_db", maxBoost)
+
+	matched := make([]string, len(clipPaths))
+	for i, clipPath := range clipPaths {
+		gain := target - loudness[i]
+		if math.IsInf(loudness[i], -1) || math.Abs(gain) < 0.1 {
+			slog.Info("Clip loudness left as is", "clip", i+1, "lufs", loudness[i])
+			matched[i] = clipPath
+			continue
+		}
+		if gain > maxBoost {
+			gain = maxBoost
+		}
+		slog.Info("Applying clip gain", "clip", i+1, "lufs", loudness[i], "gain_db", gain)
+
+		// Limit boosted clips so that they don't clip.
+		gainArgs := []string{"gain", fmt.Sprintf("%.2f", gain)}
+		if gain > 0 {
+			gainArgs = []string{"gain", "-l", fmt.Sprintf("%.2f", gain)}
+		}
+		gainPath := filepath.Join(tempDir, fmt.Sprintf("clip_%d_gain.wav", i))
+		gainPath, err := clipCache.cached(gainPath, []string{clipPath}, gainArgs, func() error {
+			if output, err := runCommand("sox", append([]string{clipPath, gainPath}, gainArgs...)...); err != nil {
+				return fmt.Errorf("failed to apply gain to clip %d: %v\nOutput: %s", i+1, err, string(output))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		matched[i] = gainPath
+	}
+	return matched, nil
+}
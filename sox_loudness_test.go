@@ -0,0 +1,103 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// feedSine feeds the meter seconds of a sine of the frequency, peak level in
+// dBFS and phase on every channel.
+func feedSine(m *LoudnessMeter, format AudioFormat, freq, level, phase, seconds float64) {
+	amplitude := math.Pow(10, level/20)
+	frame := make([]float64, format.Channels)
+	for n := 0; n < int(seconds*float64(format.Rate)); n++ {
+		x := amplitude * math.Sin(2*math.Pi*freq*float64(n)/float64(format.Rate)+phase)
+		for c := range frame {
+			frame[c] = x
+		}
+		m.Add(frame)
+	}
+}
+
+func TestLoudnessMeterIntegrated(t *testing.T) {
+	// EBU Tech 3341 case 1 and 2: a 1 kHz stereo sine at -23 and -33 dBFS
+	// measures -23 and -33 LUFS.
+	tests := []struct {
+		rate          int
+		level, wantDB float64
+	}{
+		{48000, -23, -23},
+		{48000, -33, -33},
+		{44100, -23, -23},
+	}
+	for _, tt := range tests {
+		format := AudioFormat{Rate: tt.rate, Channels: 2, Bits: 16}
+		m := NewLoudnessMeter(format)
+		feedSine(m, format, 1000, tt.level, 0, 20)
+		if got := m.Integrated(); math.Abs(got-tt.wantDB) > 0.1 {
+			t.Errorf("%d Hz, %g dBFS: Integrated() = %.2f LUFS, want %g ±0.1", tt.rate, tt.level, got, tt.wantDB)
+		}
+	}
+
+	// Mono counts a single channel, 3 dB down.
+	format := AudioFormat{Rate: 48000, Channels: 1, Bits: 16}
+	m := NewLoudnessMeter(format)
+	feedSine(m, format, 1000, -20, 0, 10)
+	if got := m.Integrated(); math.Abs(got-(-23)) > 0.1 {
+		t.Errorf("mono: Integrated() = %.2f LUFS, want -23 ±0.1", got)
+	}
+
+	// Silence, and audio shorter than a block, cannot be measured.
+	m = NewLoudnessMeter(format)
+	feedSine(m, format, 1000, -200, 0, 2)
+	if got := m.Integrated(); !math.IsInf(got, -1) {
+		t.Errorf("silence: Integrated() = %.2f LUFS, want -Inf", got)
+	}
+	m = NewLoudnessMeter(format)
+	feedSine(m, format, 1000, -20, 0, 0.3)
+	if got := m.Integrated(); !math.IsInf(got, -1) {
+		t.Errorf("300 ms: Integrated() = %.2f LUFS, want -Inf", got)
+	}
+
+	// The gating leaves out the quiet part: 10 s at -20 and 10 s at -60 dBFS
+	// measure as the loud part alone.
+	m = NewLoudnessMeter(format)
+	feedSine(m, format, 1000, -20, 0, 10)
+	feedSine(m, format, 1000, -60, 0, 10)
+	if got := m.Integrated(); math.Abs(got-(-23)) > 0.2 {
+		t.Errorf("gated: Integrated() = %.2f LUFS, want -23 ±0.2", got)
+	}
+}
+
+func TestLoudnessMeterTruePeak(t *testing.T) {
+	// A sine at a quarter of the rate, phased by π/4, is sampled only at
+	// ±0.707 while its true peak is full scale.
+	tests := []struct {
+		rate         int
+		freq, level  float64
+		phase        float64
+		want, margin float64
+	}{
+		{48000, 12000, 0, math.Pi / 4, 0, 0.5},
+		{48000, 12000, -6, math.Pi / 4, -6, 0.5},
+		{48000, 1000, -12, 0, -12, 0.1},
+		{44100, 1000, -3, 0, -3, 0.1},
+	}
+	for _, tt := range tests {
+		format := AudioFormat{Rate: tt.rate, Channels: 2, Bits: 16}
+		m := NewLoudnessMeter(format)
+		feedSine(m, format, tt.freq, tt.level, tt.phase, 1)
+		if got := m.TruePeak(); math.Abs(got-tt.want) > tt.margin {
+			t.Errorf("%d Hz at %d Hz, %g dBFS: TruePeak() = %.2f dBTP, want %g ±%g",
+				int(tt.freq), tt.rate, tt.level, got, tt.want, tt.margin)
+		}
+	}
+
+	// The sample peak of the fs/4 sine is 3 dB under its true peak.
+	format := AudioFormat{Rate: 48000, Channels: 1, Bits: 16}
+	m := NewLoudnessMeter(format)
+	feedSine(m, format, 12000, 0, math.Pi/4, 1)
+	if got := m.TruePeak(); got < -1.5 {
+		t.Errorf("TruePeak() = %.2f dBTP, want above the -3 dB sample peak", got)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// truePeakMargin is the headroom in dB kept below the true peak limit by
+// the sample peak limiter, for the inter-sample peaks.
+const truePeakMargin = 0.5
+
+// maxLimiterAttempts is the number of times the limiter is run, lowering its
+// ceiling, to get the true peak within the limit.
+const maxLimiterAttempts = 4
+
+// LoudnessStats holds the loudness measurements of an audio file.
+type LoudnessStats struct {
+	Integrated float64 `json:"integrated_lufs"`
+	Range      float64 `json:"lra_lu"`
+	TruePeak   float64 `json:"true_peak_dbtp"`
+}
+
+// LoudnessReport records the loudness normalisation of an output file.
+type LoudnessReport struct {
+	Output        string         `json:"output"`
+	Target        float64        `json:"target_lufs"`
+	TruePeakLimit float64        `json:"true_peak_limit_dbtp"`
+	Input         LoudnessStats  `json:"input"`
+	Gain          float64        `json:"gain_db"`
+	Limited       bool           `json:"limited"`
+	Ceiling       float64        `json:"ceiling_dbfs,omitempty"` // sample peak ceiling of the limiter
+	Result        *LoudnessStats `json:"result,omitempty"`
+}
+
+// ..........................................................................
+// measureLoudnessStats measures the integrated loudness, loudness range and
+// true peak of the audio file.
+func measureLoudnessStats(filePath string) (LoudnessStats, error) {
+	meter, err := meterFile(filePath)
+	if err != nil {
+		return LoudnessStats{}, err
+	}
+	stats := LoudnessStats{
+		Integrated: meter.Integrated(),
+		Range:      meter.LoudnessRange(),
+		TruePeak:   meter.TruePeak(),
+	}
+	if math.IsInf(stats.Integrated, -1) || math.IsInf(stats.TruePeak, -1) {
+		return stats, fmt.Errorf("'%s' is too short or silent to measure", filePath)
+	}
+	return stats, nil
+}
+
+// ..........................................................................
+// normaliseLoudness is the first pass of the loudness normalisation. It
+// applies the user effects to the spliced file, measures the result and
+// returns it together with the effects bringing it to the target loudness
+// within the true peak limit, for the final encode. When the peaks have to
+// be limited, the limited file is returned instead, with no effects left.
+func normaliseLoudness(clipPath string, effects []string, target, truePeakLimit float64, tempDir string) (string, []string, *LoudnessReport, error) {
+	if len(effects) > 0 {
+		processedPath := filepath.Join(tempDir, "processed.wav")
+		if output, err := runCommand("sox", append([]string{clipPath, processedPath}, effects...)...); err != nil {
+			return "", nil, nil, fmt.Errorf("failed to apply effects: %v\nOutput: %s", err, string(output))
+		}
+		clipPath = processedPath
+	}
+
+	stats, err := measureLoudnessStats(clipPath)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	report := &LoudnessReport{
+		Target:        target,
+		TruePeakLimit: truePeakLimit,
+		Input:         stats,
+		Gain:          target - stats.Integrated,
+	}
+	slog.Info("Measured loudness", "lufs", stats.Integrated, "lra", stats.Range, "true_peak_dbtp", stats.TruePeak, "gain_db", report.Gain)
+
+	if stats.TruePeak+report.Gain <= truePeakLimit {
+		return clipPath, []string{"gain", fmt.Sprintf("%.2f", report.Gain)}, report, nil
+	}
+
+	// Apply the gain and limit the peaks in one go, with a compand transfer
+	// function that is linear up to the ceiling and flat above it. The sample
+	// peak limiter lets inter-sample peaks through, so the true peak of the
+	// result is measured, and the ceiling lowered by the overshoot until it
+	// is within the limit.
+	report.Limited = true
+	limitedPath := filepath.Join(tempDir, "limited.wav")
+	ceiling := truePeakLimit - truePeakMargin
+	for attempt := 1; ; attempt++ {
+		slog.Info("Limiting peaks", "ceiling_dbfs", ceiling, "true_peak_dbtp", truePeakLimit)
+		if output, err := runCommand("sox", append([]string{clipPath, limitedPath}, limiterArgs(report.Gain, ceiling)...)...); err != nil {
+			return "", nil, nil, fmt.Errorf("failed to limit peaks: %v\nOutput: %s", err, string(output))
+		}
+		limited, err := measureLoudnessStats(limitedPath)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		overshoot := limited.TruePeak - truePeakLimit
+		if overshoot <= 0 {
+			report.Ceiling = ceiling
+			return limitedPath, nil, report, nil
+		}
+		if attempt == maxLimiterAttempts {
+			return "", nil, nil, fmt.Errorf("true peak still %.2f dBTP after limiting %d times, above the %.2f dBTP limit",
+				limited.TruePeak, attempt, truePeakLimit)
+		}
+		slog.Debug("True peak over the limit after limiting", "true_peak_dbtp", limited.TruePeak)
+		ceiling -= overshoot + 0.1
+	}
+}
+
+// limiterArgs returns the compand effect applying the gain in dB and
+// limiting the sample peaks to the ceiling in dBFS.
+func limiterArgs(gain, ceiling float64) []string {
+	transfer := fmt.Sprintf("-90,%.2f,%.2f,%.2f", -90+gain, ceiling-gain, ceiling)
+	if ceiling-gain < 0 {
+		transfer += fmt.Sprintf(",0,%.2f", ceiling)
+	}
+	return []string{"compand", "0.001,0.1", transfer, "0", "-90", "0.001"}
+}
+
+// ..........................................................................
+// writeLoudnessReport measures the normalised output, if its format can be
+// read back, and writes the JSON report next to it.
+func writeLoudnessReport(report *LoudnessReport, output string) error {
+	report.Output = output
+	if stats, err := measureLoudnessStats(output); err != nil {
+		slog.Warn("Could not measure the normalised output", "err", err)
+	} else {
+		report.Result = &stats
+		slog.Info("Output loudness", "lufs", stats.Integrated, "lra", stats.Range, "true_peak_dbtp", stats.TruePeak)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	reportPath := strings.TrimSuffix(output, filepath.Ext(output)) + ".loudness.json"
+	if err := os.WriteFile(reportPath, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	slog.Info("Loudness report saved", "path", reportPath)
+	return nil
+}
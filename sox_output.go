@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Output is a single output target, with its format options.
+type Output struct {
+	Path   string
+	FmtOpt string
+}
+
+// parseOutputs parses the output targets of file[:fopts]; targets without
+// fopts of their own take the given ones. The fopts start at the first colon
+// followed by a '-', as all sox format options do, so that colons in the
+// file name, as in a Windows drive or a time of day, are left alone. A
+// trailing colon gives the target no fopts at all. Only one target may be
+// stdout.
+func parseOutputs(specs []string, fmtOpt string) ([]Output, error) {
+	var outputs []Output
+	stdout := 0
+	for _, spec := range specs {
+		path, fopts := spec, fmtOpt
+		if i := strings.Index(spec, ":-"); i >= 0 {
+			path, fopts = spec[:i], strings.TrimSpace(spec[i+1:])
+		} else if strings.HasSuffix(spec, ":") {
+			path, fopts = strings.TrimSuffix(spec, ":"), ""
+		}
+		if path == "" {
+			return nil, fmt.Errorf("invalid output '%s', expected file[:fopts]", spec)
+		}
+		if path == "-" {
+			if stdout++; stdout > 1 {
+				return nil, fmt.Errorf("more than one output to stdout '-'")
+			}
+		}
+		outputs = append(outputs, Output{Path: path, FmtOpt: fopts})
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("no output given")
+	}
+	return outputs, nil
+}
+
+// partialPath returns the path to encode the output file into before renaming
+// it into place, in the same directory and with the same extension, for sox
+// to tell the format by.
+func partialPath(path string) string {
+	dir, base := filepath.Split(path)
+	ext := filepath.Ext(base)
+	return filepath.Join(dir, fmt.Sprintf(".%s.%d.part%s", strings.TrimSuffix(base, ext), os.Getpid(), ext))
+}
+
+// isFileOutput tells whether the output is a file, rather than one of the
+// sox special outputs, like - for stdout or -n for none.
+func isFileOutput(path string) bool {
+	return !strings.HasPrefix(path, "-")
+}
+
+// checkClobber refuses to overwrite the existing file with --no-clobber.
+func checkClobber(path string) error {
+	if !Opts.NoClobber || !isFileOutput(path) {
+		return nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return clobberError(path)
+	}
+	return nil
+}
+
+// clobberError is the error refusing to overwrite the existing file.
+func clobberError(path string) error {
+	return fmt.Errorf("'%s' exists, not overwritten with --no-clobber", path)
+}
+
+// ..........................................................................
+// encodeOutputs encodes the inputs into all the outputs concurrently, with
+// the same user effects, returning the first error.
+func encodeOutputs(inputs []string, outputs []Output, effects []string) error {
+	errs := make([]error, len(outputs))
+	progress.Phase("encode", len(outputs))
+	var wg sync.WaitGroup
+	for i, output := range outputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := encodeOutput(inputs, output, effects); err != nil {
+				errs[i] = fmt.Errorf("'%s': %v", output.Path, err)
+				return
+			}
+			slog.Info("Encoded", "output", output.Path)
+			progress.Step(0, 0, output.Path)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseOutputs(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Output
+	}{
+		{"ep.mp3", Output{"ep.mp3", "-C 128"}},
+		{"ep.mp3:-C 192", Output{"ep.mp3", "-C 192"}},
+		{"ep.flac:-b 16 -r 44100", Output{"ep.flac", "-b 16 -r 44100"}},
+		{"ep.wav:", Output{"ep.wav", ""}},
+		{`C:\out\ep.mp3`, Output{`C:\out\ep.mp3`, "-C 128"}},
+		{`C:\out\ep.mp3:-C 64`, Output{`C:\out\ep.mp3`, "-C 64"}},
+		{"ep 10:30.mp3", Output{"ep 10:30.mp3", "-C 128"}},
+		{"ep 10:30.mp3:-C 64", Output{"ep 10:30.mp3", "-C 64"}},
+		{"-", Output{"-", "-C 128"}},
+		{"-:-t wav", Output{"-", "-t wav"}},
+	}
+	for _, tt := range tests {
+		got, err := parseOutputs([]string{tt.spec}, "-C 128")
+		if err != nil {
+			t.Errorf("parseOutputs(%q): %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, []Output{tt.want}) {
+			t.Errorf("parseOutputs(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseOutputsInvalid(t *testing.T) {
+	for _, specs := range [][]string{nil, {""}, {":-C 128"}, {":"}, {"-", "ep.mp3", "-:-t wav"}} {
+		if _, err := parseOutputs(specs, ""); err == nil {
+			t.Errorf("parseOutputs(%q) succeeded, want an error", specs)
+		}
+	}
+}
+
+func TestPartialPath(t *testing.T) {
+	pid := os.Getpid()
+	tests := []struct {
+		path, want string
+	}{
+		{"ep.mp3", fmt.Sprintf(".ep.%d.part.mp3", pid)},
+		{filepath.Join("out", "ep.flac"), filepath.Join("out", fmt.Sprintf(".ep.%d.part.flac", pid))},
+		{"ep.final.wav", fmt.Sprintf(".ep.final.%d.part.wav", pid)},
+	}
+	for _, tt := range tests {
+		got := partialPath(tt.path)
+		if got != tt.want {
+			t.Errorf("partialPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+		if filepath.Dir(got) != filepath.Dir(tt.path) || filepath.Ext(got) != filepath.Ext(tt.path) {
+			t.Errorf("partialPath(%q) = %q, want the same directory and extension", tt.path, got)
+		}
+	}
+}
+
+func TestCheckClobber(t *testing.T) {
+	existing := writeFile(t, "ep.mp3", "audio")
+	missing := filepath.Join(t.TempDir(), "new.mp3")
+	defer func(noClobber bool) { Opts.NoClobber = noClobber }(Opts.NoClobber)
+
+	tests := []struct {
+		noClobber bool
+		path      string
+		wantErr   bool
+	}{
+		{false, existing, false},
+		{false, missing, false},
+		{true, existing, true},
+		{true, missing, false},
+		{true, "-", false},
+		{true, "-n", false},
+	}
+	for _, tt := range tests {
+		Opts.NoClobber = tt.noClobber
+		if err := checkClobber(tt.path); (err != nil) != tt.wantErr {
+			t.Errorf("checkClobber(%q) with no-clobber %v = %v, want error %v", tt.path, tt.noClobber, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Preset bundles the output format options with a final effect chain.
+type Preset struct {
+	FmtOpt  string `yaml:"fopts"`
+	Effects string `yaml:"effects"`
+}
+
+// outputPreset is the encoder preset named on the cli, nil for none.
+var outputPreset *Preset
+
+// builtinPresets are the presets available without any presets file.
+var builtinPresets = map[string]Preset{
+	"podcast-mp3":  {FmtOpt: "-C 128", Effects: "highpass 80 rate 44100"},
+	"archive-flac": {FmtOpt: "-C 8 -b 24"},
+	"voice-opus":   {FmtOpt: "-C 16", Effects: "highpass 80 remix - rate 48000"},
+}
+
+// presetsFile returns the path of the user presets file.
+func presetsFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "soxcut", "presets.yaml"), nil
+}
+
+// setPreset resolves the encoder preset named on the cli, before any work.
+func setPreset() {
+	var err error
+	if outputPreset, err = resolvePreset(Opts.Preset); err != nil {
+		log.Fatalf("Failed to resolve the preset: %v", err)
+	}
+}
+
+// applyPreset returns the fopts and the effects to encode with: the encoder
+// preset ones after the user effects, the preset fopts giving way to the
+// user ones.
+func applyPreset(args []string) (string, []string) {
+	preset := outputPreset
+	if preset == nil {
+		return Opts.FmtOpt, args
+	}
+	slog.Info("Using preset", "preset", Opts.Preset, "fopts", preset.FmtOpt, "effects", preset.Effects)
+	fmtOpt := Opts.FmtOpt
+	if fmtOpt == "" {
+		fmtOpt = preset.FmtOpt
+	}
+	return fmtOpt, append(args, strings.Fields(preset.Effects)...)
+}
+
+// ..........................................................................
+// resolvePreset returns the named preset, from the user presets file first
+// and then the built-in ones; nil when no preset is named.
+func resolvePreset(name string) (*Preset, error) {
+	if name == "" {
+		return nil, nil
+	}
+	presets, err := loadPresets()
+	if err != nil {
+		return nil, err
+	}
+	preset, ok := presets[name]
+	if !ok {
+		var names []string
+		for name := range presets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown preset '%s', expected one of: %s", name, strings.Join(names, ", "))
+	}
+	return &preset, nil
+}
+
+// loadPresets returns the built-in presets, overridden and extended by the
+// ones in the user presets file, if any, of the form:
+//
+//	name:
+//	  fopts: -C 192
+//	  effects: highpass 80 gain -1
+func loadPresets() (map[string]Preset, error) {
+	presets := map[string]Preset{}
+	for name, preset := range builtinPresets {
+		presets[name] = preset
+	}
+
+	path, err := presetsFile()
+	if err != nil {
+		return presets, nil // No home directory, no user presets
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var user map[string]Preset
+	if err := yaml.Unmarshal(data, &user); err != nil {
+		return nil, fmt.Errorf("invalid presets file '%s': %w", path, err)
+	}
+	for name, preset := range user {
+		presets[name] = preset
+	}
+	return presets, nil
+}
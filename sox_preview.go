@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//==========================================================================
+// Main entrance
+
+// ..........................................................................
+// soxpreview renders only the audio around each joint, for quick auditioning.
+func soxpreview(args []string) {
+	x := previewCommand
+	setDurations()
+	checkOptions()
+	setPreset()
+
+	// Dependency Check: Ensure sox is installed.
+	if !commandExists("sox") {
+		log.Fatal("SoX not found in PATH. Please install it to continue.")
+	}
+	openClipCache()
+
+	slog.Info("Joint Previewer started")
+	tempDir := makeTempDir()
+	defer removeTempDir(tempDir)
+
+	var clipPaths []string
+	var joints []Joint
+	if x.FileI != "" {
+		inputFile, timingsFile = x.FileI, x.FileS
+		if timingsFile == "" {
+			log.Fatal("The segments file is required with the input file.")
+		}
+		clipPaths, joints = extractClips(tempDir)
+	} else {
+		inputFile = x.FileList
+		if inputFile == "" {
+			inputFile = x.Dir
+		}
+		clipPaths, joints = joinClips(x.FileList, x.Dir, AudioFormat{}, tempDir)
+	}
+	if len(clipPaths) < 2 {
+		log.Fatal("No joints to preview. Exiting.")
+	}
+
+	fmtOpt, args := applyPreset(args)
+	outputs, err := parseOutputs(Opts.FileO, fmtOpt)
+	if err != nil {
+		log.Fatalf("Failed to parse the outputs: %v", err)
+	}
+	output := outputs[0]
+	if err := checkClobber(output.Path); err != nil && !x.Split {
+		log.Fatalf("Refusing to render: %v", err)
+	}
+	clipPaths = levelClips(clipPaths, tempDir)
+	joints, err = forceOffsets(joints, len(clipPaths), Opts.ForceOffset)
+	if err != nil {
+		log.Fatalf("Failed to force offsets: %v", err)
+	}
+	before := time.Duration(x.Before) * time.Millisecond
+	after := time.Duration(x.After) * time.Millisecond
+	previews, err := renderJointPreviews(clipPaths, joints, before, after, tempDir)
+	if err != nil {
+		log.Fatalf("Failed during joint preview rendering: %v", err)
+	}
+
+	if x.Split {
+		for i, preview := range previews {
+			numbered := Output{Path: numberedOutput(output.Path, i+1), FmtOpt: output.FmtOpt}
+			if err := encodeOutput([]string{preview}, numbered, args); err != nil {
+				log.Fatalf("Failed to encode preview of joint %d: %v", i+1, err)
+			}
+			slog.Info("Preview of joint saved", "joint", i+1, "output", numbered.Path)
+		}
+		return
+	}
+
+	separator, err := makeSeparator(x.Separator, previews[0], tempDir)
+	if err != nil {
+		log.Fatalf("Failed to create the separator: %v", err)
+	}
+	var inputs []string
+	for i, preview := range previews {
+		if i > 0 {
+			inputs = append(inputs, separator)
+		}
+		inputs = append(inputs, preview)
+	}
+	if err := encodeOutput(inputs, output, args); err != nil {
+		log.Fatalf("Failed to encode the previews: %v", err)
+	}
+	slog.Info("Previews of joints saved", "joints", len(previews), "output", output.Path)
+}
+
+//==========================================================================
+// Support functions
+
+// ..........................................................................
+// renderJointPreviews splices, for each joint, the last part of the clip
+// before it onto the first part of the clip after it, with the very same
+// joint parameters as the full render. Each preview holds about `before`
+// audio up to the joint and `after` audio past it.
+func renderJointPreviews(clipPaths []string, joints []Joint, before, after time.Duration, tempDir string) ([]string, error) {
+	var previews []string
+	for i := 1; i < len(clipPaths); i++ {
+		joint := jointAt(joints, i-1)
+
+		// The clip before holds the excess past the joint on its end,
+		// and the clip after holds the excess and leeway before it,
+		// unless there is a gap at the joint.
+		prevDuration, err := getAudioDuration(clipPaths[i-1])
+		if err != nil {
+			return nil, fmt.Errorf("could not get duration of '%s': %v", clipPaths[i-1], err)
+		}
+		excess, leeway := joint.Excess, joint.Leeway
+		if joint.Gap.Duration > 0 {
+			excess, leeway = 0, 0
+		}
+		tailStart := max(prevDuration-excess-before, 0)
+		headDuration := excess + leeway + after + excess
+
+		tailPath := filepath.Join(tempDir, fmt.Sprintf("joint_%d_tail.wav", i))
+		tailPath, err = trimClip(clipPaths[i-1], tailPath, tailStart, 0)
+		if err != nil {
+			return nil, err
+		}
+		headPath := filepath.Join(tempDir, fmt.Sprintf("joint_%d_head.wav", i))
+		headPath, err = trimClip(clipPaths[i], headPath, 0, headDuration)
+		if err != nil {
+			return nil, err
+		}
+
+		previewPath := filepath.Join(tempDir, fmt.Sprintf("joint_%d_preview.wav", i))
+		previewPath, err = spliceJoint(tailPath, headPath, &joint, previewPath, i+1)
+		if err != nil {
+			return nil, err
+		}
+		previews = append(previews, previewPath)
+	}
+	return previews, nil
+}
+
+// trimClip trims the clip from start for duration into outPath, 0 duration
+// for up to the end, returning the resulting file.
+func trimClip(clipPath, outPath string, start, duration time.Duration) (string, error) {
+	trimArgs := []string{"trim", fmt.Sprintf("%f", start.Seconds())}
+	if duration > 0 {
+		trimArgs = append(trimArgs, fmt.Sprintf("%f", duration.Seconds()))
+	}
+	return clipCache.cached(outPath, []string{clipPath}, trimArgs, func() error {
+		if output, err := runCommand("sox", append([]string{clipPath, outPath}, trimArgs...)...); err != nil {
+			return fmt.Errorf("failed to trim '%s': %v\nOutput: %s", clipPath, err, string(output))
+		}
+		return nil
+	})
+}
+
+// makeSeparator creates a short beep or silence in the sample format of
+// the given clip, to put between the previews.
+func makeSeparator(kind, likeClip, tempDir string) (string, error) {
+	format, err := getAudioFormat(likeClip)
+	if err != nil {
+		return "", fmt.Errorf("could not get format of '%s': %v", likeClip, err)
+	}
+
+	sepPath := filepath.Join(tempDir, "separator.wav")
+	soxArgs := []string{"-n", "-r", strconv.Itoa(format.Rate), "-c", strconv.Itoa(format.Channels),
+		"-b", strconv.Itoa(wavBits(format.Bits)), sepPath}
+	switch kind {
+	case "beep":
+		soxArgs = append(soxArgs, "synth", "0.15", "sine", "1000", "gain", "-15", "pad", "0.4", "0.4")
+	case "silence":
+		soxArgs = append(soxArgs, "trim", "0", "1")
+	default:
+		return "", fmt.Errorf("unknown separator '%s', expected beep or silence", kind)
+	}
+
+	if output, err := runCommand("sox", soxArgs...); err != nil {
+		return "", fmt.Errorf("%v\nOutput: %s", err, string(output))
+	}
+	return sepPath, nil
+}
+
+// numberedOutput inserts the number before the extension of the output file,
+// e.g. "output.mp3" becomes "output-01.mp3".
+func numberedOutput(output string, n int) string {
+	ext := filepath.Ext(output)
+	return fmt.Sprintf("%s-%02d%s", strings.TrimSuffix(output, ext), n, ext)
+}
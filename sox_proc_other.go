@@ -0,0 +1,17 @@
+//go:build !unix
+
+package main
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup leaves the command as is, killed alone when runCtx is
+// cancelled, as there are no process groups to kill.
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+// terminateOnCancel leaves the command to be killed when runCtx is cancelled,
+// as there is no signal to terminate it with.
+func terminateOnCancel(cmd *exec.Cmd) {
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs the command in a process group of its own, killed as a
+// whole when runCtx is cancelled.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+}
+
+// terminateOnCancel has the command, a soxcut of its own, terminated rather
+// than killed when runCtx is cancelled, for it to shut down cleanly.
+func terminateOnCancel(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+}
+
+// killProcessGroup kills the process group of the started command.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress reporting modes.
+const (
+	progressAuto = "auto"
+	progressBar  = "bar"
+	progressJSON = "json"
+	progressNone = "none"
+)
+
+// progressBarWidth is the width of the TTY progress bar in characters.
+const progressBarWidth = 30
+
+// progressPhases are the phases of a render in order, with their rough share
+// of its time, for the ETA of the whole render. Phases not listed, like the
+// batch, make up the whole run.
+var progressPhases = []struct {
+	name   string
+	weight float64
+}{{"trim", 0.3}, {"splice", 0.4}, {"encode", 0.3}}
+
+// ProgressEvent is a single progress report, emitted as a line of JSON.
+type ProgressEvent struct {
+	Phase   string  `json:"phase"`
+	Clip    int     `json:"clip,omitempty"`  // from 1
+	Joint   int     `json:"joint,omitempty"` // from 1, the joint before clip Joint+1
+	Done    int     `json:"done"`
+	Total   int     `json:"total"`
+	Elapsed float64 `json:"elapsed"` // seconds since the start
+	Bytes   int64   `json:"bytes"`   // written so far, in total
+}
+
+// Progress tracks the steps done in each phase of the processing, and
+// reports them either as a TTY progress bar on stderr or as JSON events on
+// stdout. A nil Progress reports nothing.
+type Progress struct {
+	mode  string
+	start time.Time
+	phase string
+	done  int
+	total int
+	bytes int64
+	mu    sync.Mutex
+}
+
+// progress is the progress of the current run, nil when not reported.
+var progress *Progress
+
+// startProgress starts reporting progress in the given mode; auto shows the
+// bar only when stderr is a terminal.
+func startProgress(mode string) {
+	if mode == progressAuto {
+		mode = progressNone
+		if info, err := os.Stderr.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			mode = progressBar
+		}
+	}
+	if mode == progressNone || mode == "" {
+		return
+	}
+	progress = &Progress{mode: mode, start: time.Now()}
+}
+
+// ..........................................................................
+// Phase starts a new phase of total steps.
+func (p *Progress) Phase(name string, total int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mode == progressBar && p.phase != "" {
+		fmt.Fprintln(os.Stderr)
+	}
+	p.phase, p.done, p.total = name, 0, total
+	p.report(0, 0)
+}
+
+// Step records a step of the current phase done on the clip and joint, 0 for
+// none, which wrote the file, "" for none.
+func (p *Progress) Step(clip, joint int, written string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if info, err := os.Stat(written); written != "" && err == nil {
+		p.bytes += info.Size()
+	}
+	p.report(clip, joint)
+}
+
+// Finish reports the end of the processing.
+func (p *Progress) Finish() {
+	if p == nil {
+		return
+	}
+	if p.mode == progressBar {
+		fmt.Fprintln(os.Stderr)
+		return
+	}
+	p.Phase("done", 0)
+}
+
+// report emits the current progress, with the lock held.
+func (p *Progress) report(clip, joint int) {
+	if p.mode == progressJSON {
+		data, _ := json.Marshal(ProgressEvent{
+			Phase: p.phase, Clip: clip, Joint: joint, Done: p.done, Total: p.total,
+			Elapsed: time.Since(p.start).Seconds(), Bytes: p.bytes,
+		})
+		fmt.Println(string(data))
+		return
+	}
+	p.draw()
+}
+
+// draw draws the bar of the current phase, with the ETA of the whole run
+// from the pace so far, with the lock held.
+func (p *Progress) draw() {
+	elapsed := time.Since(p.start)
+	filled := 0
+	if p.total > 0 {
+		filled = progressBarWidth * p.done / p.total
+	}
+	eta := "--:--"
+	if done := p.fraction(); done > 0 {
+		eta = formatETA(time.Duration(float64(elapsed) * (1 - done) / done))
+	}
+	fmt.Fprintf(os.Stderr, "\r%-7s [%s%s] %d/%d  elapsed %s  ETA %s ", p.phase,
+		strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled),
+		p.done, p.total, formatETA(elapsed), eta)
+}
+
+// fraction returns the fraction of the whole run done, weighting the phases
+// by their share of it.
+func (p *Progress) fraction() float64 {
+	phase := 0.0
+	if p.total > 0 {
+		phase = float64(p.done) / float64(p.total)
+	}
+	before, weight, sum := 0.0, -1.0, 0.0
+	for _, ph := range progressPhases {
+		sum += ph.weight
+		if ph.name == p.phase {
+			weight = ph.weight
+		} else if weight < 0 {
+			before += ph.weight
+		}
+	}
+	if weight < 0 {
+		return phase
+	}
+	return (before + weight*phase) / sum
+}
+
+// progressLog is the stderr the log records are written to, clearing the
+// progress bar before each one and drawing it again after.
+type progressLog struct{}
+
+func (progressLog) Write(record []byte) (int, error) {
+	p := progress
+	if p == nil || p.mode != progressBar {
+		return os.Stderr.Write(record)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.phase == "" {
+		return os.Stderr.Write(record)
+	}
+	fmt.Fprint(os.Stderr, "\r\033[K")
+	n, err := os.Stderr.Write(record)
+	p.draw()
+	return n, err
+}
+
+// formatETA formats the duration as [H:]MM:SS.
+func formatETA(d time.Duration) string {
+	s := int(d.Round(time.Second).Seconds())
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%02d:%02d", s/60, s%60)
+}
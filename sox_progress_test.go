@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestProgressFraction(t *testing.T) {
+	tests := []struct {
+		phase       string
+		done, total int
+		want        float64
+	}{
+		{"trim", 0, 10, 0},
+		{"trim", 5, 10, 0.15},
+		{"trim", 10, 10, 0.3},
+		{"splice", 0, 4, 0.3},
+		{"splice", 1, 4, 0.4},
+		{"splice", 0, 0, 0.3},
+		{"encode", 1, 2, 0.85},
+		{"encode", 2, 2, 1},
+		// A phase not listed makes up the whole run.
+		{"batch", 3, 4, 0.75},
+		{"batch", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		p := &Progress{phase: tt.phase, done: tt.done, total: tt.total}
+		if got := p.fraction(); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("fraction(%s %d/%d) = %g, want %g", tt.phase, tt.done, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "00:00"},
+		{400 * time.Millisecond, "00:00"},
+		{1500 * time.Millisecond, "00:02"},
+		{59 * time.Second, "00:59"},
+		{61 * time.Second, "01:01"},
+		{59*time.Minute + 59*time.Second, "59:59"},
+		{time.Hour, "1:00:00"},
+		{25*time.Hour + 2*time.Minute + 3*time.Second, "25:02:03"},
+	}
+	for _, tt := range tests {
+		if got := formatETA(tt.d); got != tt.want {
+			t.Errorf("formatETA(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
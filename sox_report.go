@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// Report is the record of a run, for auditing and reproducing it: what went
+// in, how it was cut and joined, what was run to do it and what came out.
+// Times are in seconds. A nil Report records nothing.
+type Report struct {
+	Version  string            `json:"version"`
+	Command  string            `json:"command"`
+	Args     []string          `json:"args"` // the user sox effects
+	Options  map[string]string `json:"options"`
+	Inputs   []ReportInput     `json:"inputs"`
+	Segments []ReportSegment   `json:"segments"`
+	Joints   []TimelineJoint   `json:"joints"`
+	Stingers []TimelineStinger `json:"stingers,omitempty"`
+	Commands []ReportCommand   `json:"commands"`
+	Outputs  []ReportOutput    `json:"outputs"`
+
+	inputs map[string]bool
+	mu     sync.Mutex
+}
+
+// ReportInput is an input file of the run.
+type ReportInput struct {
+	Path     string  `json:"path"`
+	SHA256   string  `json:"sha256"`
+	Duration float64 `json:"duration"`
+}
+
+// ReportSegment is a segment as requested, in the segments or list file, and
+// as trimmed from its source, with the excess/leeway around its joints. Zero
+// ends are the ends of the source.
+type ReportSegment struct {
+	Clip      int     `json:"clip"` // from 1
+	Source    string  `json:"source"`
+	Start     float64 `json:"start"`
+	End       float64 `json:"end"`
+	TrimStart float64 `json:"trim_start"`
+	TrimEnd   float64 `json:"trim_end"`
+	Trimmed   bool    `json:"trimmed"` // false when used as is
+}
+
+// ReportCommand is an external command run, and how long it took.
+type ReportCommand struct {
+	Args     []string `json:"args"`
+	Duration float64  `json:"duration"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// ReportOutput is an output file of the run.
+type ReportOutput struct {
+	Path     string  `json:"path"`
+	FmtOpt   string  `json:"fopts,omitempty"`
+	Rate     int     `json:"rate"`
+	Channels int     `json:"channels"`
+	Bits     int     `json:"bits"`
+	Duration float64 `json:"duration"`
+	Size     int64   `json:"size"`
+}
+
+// report is the report of the current run, nil when not asked for.
+var report *Report
+
+// startReport starts recording the run of the command with the user effects,
+// if a report file is asked for.
+func startReport(path, command string, args []string) {
+	if path == "" {
+		return
+	}
+	report = &Report{
+		Version: version, Command: command, Args: append([]string{}, args...),
+		Options: map[string]string{}, Inputs: []ReportInput{}, Segments: []ReportSegment{},
+		Commands: []ReportCommand{}, Outputs: []ReportOutput{}, inputs: map[string]bool{},
+	}
+	for _, option := range commandOptions(gfParser.Active) {
+		report.Options[option.LongName] = optionValue(option)
+	}
+}
+
+// ..........................................................................
+// Input records an input file, hashed when the report is written.
+func (r *Report) Input(path string) {
+	if r == nil || path == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.inputs[path] {
+		r.inputs[path] = true
+		r.Inputs = append(r.Inputs, ReportInput{Path: path})
+	}
+}
+
+// Segment records the requested segment of clip, from 1, in the source.
+func (r *Report) Segment(clip int, source string, start, end time.Duration) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	segment := r.segment(clip)
+	segment.Source, segment.Start, segment.End = source, start.Seconds(), end.Seconds()
+	segment.TrimStart, segment.TrimEnd = segment.Start, segment.End
+}
+
+// Trim records the part of the source actually trimmed for clip, from 1.
+func (r *Report) Trim(clip int, start, end time.Duration) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	segment := r.segment(clip)
+	segment.TrimStart, segment.TrimEnd, segment.Trimmed = start.Seconds(), end.Seconds(), true
+}
+
+// segment returns the segment of clip, from 1, with the lock held.
+func (r *Report) segment(clip int) *ReportSegment {
+	for len(r.Segments) < clip {
+		r.Segments = append(r.Segments, ReportSegment{Clip: len(r.Segments) + 1})
+	}
+	return &r.Segments[clip-1]
+}
+
+// Ran records an external command run for the duration.
+func (r *Report) Ran(args []string, duration time.Duration, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	command := ReportCommand{Args: args, Duration: duration.Seconds()}
+	if err != nil {
+		command.Error = err.Error()
+	}
+	r.Commands = append(r.Commands, command)
+}
+
+// ..........................................................................
+// Write completes the report with the joints, the input hashes and the
+// outputs, and saves it to path.
+func (r *Report) Write(path string, joints []Joint, outputs []Output) error {
+	if r == nil {
+		return nil
+	}
+	// Leave out the commands run to complete the report itself.
+	r.mu.Lock()
+	commands := len(r.Commands)
+	r.mu.Unlock()
+
+	r.Joints, r.Stingers = timelineJoints(joints)
+	for i, input := range r.Inputs {
+		hash, err := hashFile(input.Path)
+		if err != nil {
+			return err
+		}
+		duration, err := getAudioDuration(input.Path)
+		if err != nil {
+			return err
+		}
+		r.Inputs[i].SHA256, r.Inputs[i].Duration = hash, duration.Seconds()
+	}
+	for _, output := range outputs {
+		if !isFileOutput(output.Path) {
+			r.Outputs = append(r.Outputs, ReportOutput{Path: output.Path, FmtOpt: output.FmtOpt})
+			continue
+		}
+		format, err := getAudioFormat(output.Path)
+		if err != nil {
+			return err
+		}
+		duration, err := getAudioDuration(output.Path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(output.Path)
+		if err != nil {
+			return err
+		}
+		r.Outputs = append(r.Outputs, ReportOutput{
+			Path: output.Path, FmtOpt: output.FmtOpt, Rate: format.Rate,
+			Channels: format.Channels, Bits: format.Bits,
+			Duration: duration.Seconds(), Size: info.Size(),
+		})
+	}
+
+	r.mu.Lock()
+	r.Commands = r.Commands[:commands]
+	data, err := json.MarshalIndent(r, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
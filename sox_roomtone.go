@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Room tone looping and detection.
+const (
+	roomToneAuto   = "auto"
+	roomToneLength = time.Second            // of the auto-detected region
+	roomToneFrame  = 50 * time.Millisecond  // of the auto detection
+	roomToneFade   = 100 * time.Millisecond // of the cross-fade between loops
+)
+
+// roomToneSource is the source to take the room tone for the head and tail
+// padding from.
+var roomToneSource string
+
+// roomTones holds the room tone region found for each source.
+var roomTones = map[string]*ClipTiming{}
+
+// ..........................................................................
+// roomToneRegion returns the region of the source with the room tone set by
+// the cli, nil for none: either the auto-detected quietest stretch of each
+// source, or the region given, of the room tone source only.
+func roomToneRegion(source string) (*ClipTiming, error) {
+	if Opts.RoomTone == "" {
+		return nil, nil
+	}
+	if Opts.RoomTone != roomToneAuto && source != roomToneSource {
+		return nil, fmt.Errorf("room tone '%s' is a region of '%s', the gap in '%s' needs a tone= region of its own",
+			Opts.RoomTone, roomToneSource, source)
+	}
+	if region, ok := roomTones[source]; ok {
+		return region, nil
+	}
+
+	var region *ClipTiming
+	var err error
+	if Opts.RoomTone == roomToneAuto {
+		region, err = findQuietestRegion(source, roomToneLength)
+		if err == nil {
+			slog.Info("Room tone detected", "source", source, "start", region.Start, "end", region.End)
+		}
+	} else {
+		region, err = parseRegion(Opts.RoomTone)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid room tone '%s': %v", Opts.RoomTone, err)
+	}
+	roomTones[source] = region
+	return region, nil
+}
+
+// findQuietestRegion returns the stretch of the given length with the least
+// energy in the source, leaving out digital silence which is no room tone.
+func findQuietestRegion(source string, length time.Duration) (*ClipTiming, error) {
+	format, err := getAudioFormat(source)
+	if err != nil {
+		return nil, fmt.Errorf("could not get format of '%s': %v", source, err)
+	}
+
+	// Mean square of each frame.
+	frameSize := int(roomToneFrame.Seconds() * float64(format.Rate))
+	var powers []float64
+	var sum float64
+	var n int
+	err = streamSamples(source, format, func(frame []float64) {
+		for _, v := range frame {
+			sum += v * v / float64(len(frame))
+		}
+		if n++; n == frameSize {
+			powers = append(powers, sum/float64(n))
+			sum, n = 0, 0
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Sliding sum over the region length, skipping windows with silence.
+	window := min(int(length/roomToneFrame), len(powers))
+	silence := math.Pow(10, silenceDB/10)
+	best, bestSum := -1, math.Inf(1)
+	for start := 0; start+window <= len(powers); start++ {
+		var total float64
+		quiet := true
+		for _, p := range powers[start : start+window] {
+			if p <= silence {
+				quiet = false
+				break
+			}
+			total += p
+		}
+		if quiet && total < bestSum {
+			best, bestSum = start, total
+		}
+	}
+	if best < 0 {
+		return nil, fmt.Errorf("no room tone found in '%s'", source)
+	}
+	return &ClipTiming{
+		Start: time.Duration(best) * roomToneFrame,
+		End:   time.Duration(best+window) * roomToneFrame,
+	}, nil
+}
+
+// ..........................................................................
+// loopRoomTone writes the room tone region of the source, looped with
+// cross-fades to the given duration, into outPath in the given sample format.
+func loopRoomTone(source string, region ClipTiming, duration time.Duration, format AudioFormat, outPath string) error {
+	sourceFormat, err := getAudioFormat(source)
+	if err != nil {
+		return fmt.Errorf("could not get format of '%s': %v", source, err)
+	}
+	channels, err := readSamples(source, sourceFormat, region.Start, region.End-region.Start)
+	if err != nil {
+		return err
+	}
+	if len(channels[0]) == 0 {
+		return fmt.Errorf("room tone region %v-%v is past the end of '%s'", region.Start, region.End, source)
+	}
+
+	length := int(math.Round(duration.Seconds() * float64(sourceFormat.Rate)))
+	for c, tone := range channels {
+		channels[c] = loopSamples(tone, length,
+			min(int(roomToneFade.Seconds()*float64(sourceFormat.Rate)), len(tone)/4))
+	}
+
+	formatArgs := []string{"-r", strconv.Itoa(format.Rate), "-c", strconv.Itoa(format.Channels),
+		"-b", strconv.Itoa(wavBits(format.Bits))}
+	return writeSamples(outPath, sourceFormat.Rate, channels, formatArgs, gapFadeArgs(true, true))
+}
+
+// loopSamples repeats the samples to the given length, with equal power
+// cross-fades of fade samples between the repeats, as room tone is
+// uncorrelated with itself.
+func loopSamples(samples []float64, length, fade int) []float64 {
+	looped := append(make([]float64, 0, length+len(samples)), samples...)
+	for len(looped) < length {
+		end := len(looped) - fade
+		for k := range fade {
+			theta := math.Pi / 2 * (float64(k) + 0.5) / float64(fade)
+			looped[end+k] = looped[end+k]*math.Cos(theta) + samples[k]*math.Sin(theta)
+		}
+		looped = append(looped, samples[fade:]...)
+	}
+	return looped[:length]
+}
+
+// ..........................................................................
+// parsePad parses the head and tail padding of HEAD[,TAIL] ms; the tail
+// defaults to the head.
+func parsePad(s string) (time.Duration, time.Duration, error) {
+	head, tail, ok := strings.Cut(s, ",")
+	if !ok {
+		tail = head
+	}
+	headPad, err := parseMilliseconds(head)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid head padding '%s': %w", head, err)
+	}
+	tailPad, err := parseMilliseconds(tail)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid tail padding '%s': %w", tail, err)
+	}
+	return headPad, tailPad, nil
+}
+
+// padClip pads the head and tail of the clip with room tone, or silence if
+// none is set, returning the resulting file.
+func padClip(clipPath string, head, tail time.Duration, tempDir string) (string, error) {
+	format, err := getAudioFormat(clipPath)
+	if err != nil {
+		return "", fmt.Errorf("could not get format of '%s': %v", clipPath, err)
+	}
+	inputs := []string{clipPath}
+	for i, pad := range []time.Duration{head, tail} {
+		if pad <= 0 {
+			continue
+		}
+		padPath := filepath.Join(tempDir, fmt.Sprintf("pad_%d.wav", i))
+		padPath, err = makeGap(Gap{Duration: pad, Source: roomToneSource}, format, padPath)
+		if err != nil {
+			return "", err
+		}
+		if i == 0 {
+			inputs = append([]string{padPath}, inputs...)
+		} else {
+			inputs = append(inputs, padPath)
+		}
+	}
+
+	slog.Info("Padding with room tone", "head", head, "tail", tail)
+	paddedPath := filepath.Join(tempDir, "padded.wav")
+	return clipCache.cached(paddedPath, inputs, nil, func() error {
+		if output, err := runCommand("sox", append(inputs, paddedPath)...); err != nil {
+			return fmt.Errorf("%v\nOutput: %s", err, string(output))
+		}
+		return nil
+	})
+}
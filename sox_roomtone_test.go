@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePad(t *testing.T) {
+	tests := []struct {
+		s          string
+		head, tail time.Duration
+	}{
+		{"500", 500 * time.Millisecond, 500 * time.Millisecond},
+		{"500,1000", 500 * time.Millisecond, time.Second},
+		{"0,250.5", 0, 250500 * time.Microsecond},
+	}
+	for _, tt := range tests {
+		head, tail, err := parsePad(tt.s)
+		if err != nil {
+			t.Errorf("parsePad(%q): %v", tt.s, err)
+			continue
+		}
+		if head != tt.head || tail != tt.tail {
+			t.Errorf("parsePad(%q) = %v, %v, want %v, %v", tt.s, head, tail, tt.head, tt.tail)
+		}
+	}
+
+	for _, s := range []string{"", "-5", "500,", ",500", "a,b", "1,2,3"} {
+		if _, _, err := parsePad(s); err == nil {
+			t.Errorf("parsePad(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestRoomToneRegion(t *testing.T) {
+	defer func(roomTone, source string) {
+		Opts.RoomTone, roomToneSource = roomTone, source
+		roomTones = map[string]*ClipTiming{}
+	}(Opts.RoomTone, roomToneSource)
+	roomTones = map[string]*ClipTiming{}
+	Opts.RoomTone, roomToneSource = "00:01-00:03", "first.wav"
+
+	region, err := roomToneRegion("first.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ClipTiming{Start: time.Second, End: 3 * time.Second}); region == nil || *region != want {
+		t.Errorf("roomToneRegion(first.wav) = %+v, want %+v", region, want)
+	}
+	if region, err := roomToneRegion("second.wav"); err == nil {
+		t.Errorf("roomToneRegion(second.wav) = %+v, want an error for a region of another source", region)
+	}
+
+	Opts.RoomTone = ""
+	if region, err := roomToneRegion("second.wav"); region != nil || err != nil {
+		t.Errorf("roomToneRegion() without room tone = %+v, %v; want none", region, err)
+	}
+}
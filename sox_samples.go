@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ..........................................................................
+// streamSamples decodes the audio file with sox and calls fn with every
+// frame, one sample per channel, in the range [-1, 1]. Extra sox effects,
+// e.g. a trim, may be given to only stream a part of the file. The frame
+// slice is reused between calls.
+func streamSamples(filePath string, format AudioFormat, fn func(frame []float64), effects ...string) error {
+	//   sox <input> -t raw -e floating-point -b 32 -L - <effects>
+	soxArgs := []string{filePath, "-t", "raw", "-e", "floating-point", "-b", "32", "-L", "-"}
+	cmd := newCommand("sox", append(soxArgs, effects...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	start := logCommand(cmd)
+	if err := startCommand(cmd); err != nil {
+		return err
+	}
+	defer func() { logCommandDone(cmd, start, stderr.Bytes(), nil) }()
+
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+	buf := make([]byte, 4*format.Channels)
+	frame := make([]float64, format.Channels)
+	for {
+		if _, err = io.ReadFull(reader, buf); err != nil {
+			break
+		}
+		for c := range frame {
+			frame[c] = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[4*c:])))
+		}
+		fn(frame)
+	}
+	if err != io.EOF && err != io.ErrUnexpectedEOF {
+		cmd.Process.Kill()
+		waitCommand(cmd)
+		return fmt.Errorf("failed to read samples of '%s': %v", filePath, err)
+	}
+	if err := waitCommand(cmd); err != nil {
+		return fmt.Errorf("failed to decode '%s': %v\nOutput: %s", filePath, err, stderr.String())
+	}
+	return nil
+}
+
+// ..........................................................................
+// readSamples decodes the part of the audio file from start for duration
+// into memory, one slice of samples per channel.
+func readSamples(filePath string, format AudioFormat, start, duration time.Duration) ([][]float64, error) {
+	channels := make([][]float64, format.Channels)
+	err := streamSamples(filePath, format, func(frame []float64) {
+		for c, v := range frame {
+			channels[c] = append(channels[c], v)
+		}
+	}, "trim", fmt.Sprintf("%f", start.Seconds()), fmt.Sprintf("%f", duration.Seconds()))
+	return channels, err
+}
+
+// ..........................................................................
+// writeSamples encodes the samples, one slice per channel at the given rate,
+// into outPath with sox, with the output format options and effects.
+func writeSamples(outPath string, rate int, channels [][]float64, formatArgs, effects []string) error {
+	frame := make([]float64, len(channels))
+	return encodeSamples(outPath, rate, len(channels), formatArgs, effects, func(emit func(frame []float64) error) error {
+		for i := range channels[0] {
+			for c, samples := range channels {
+				frame[c] = samples[i]
+			}
+			if err := emit(frame); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// encodeSamples encodes the frames, at the given rate and channel count, that
+// fill passes to emit into outPath with sox, with the output format options
+// and effects, without holding them all in memory.
+func encodeSamples(outPath string, rate, channelCount int, formatArgs, effects []string, fill func(emit func(frame []float64) error) error) error {
+	//   sox -t raw -e floating-point -b 32 -L -r <rate> -c <channels> - <fopts> <output> <effects>
+	soxArgs := []string{"-t", "raw", "-e", "floating-point", "-b", "32", "-L",
+		"-r", strconv.Itoa(rate), "-c", strconv.Itoa(channelCount), "-"}
+	soxArgs = append(soxArgs, formatArgs...)
+	soxArgs = append(soxArgs, outPath)
+	cmd := newCommand("sox", append(soxArgs, effects...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	start := logCommand(cmd)
+	if err := startCommand(cmd); err != nil {
+		return err
+	}
+	defer func() { logCommandDone(cmd, start, stderr.Bytes(), nil) }()
+
+	writer := bufio.NewWriterSize(stdin, 64*1024)
+	buf := make([]byte, 4*channelCount)
+	err = fill(func(frame []float64) error {
+		for c, v := range frame {
+			binary.LittleEndian.PutUint32(buf[4*c:], math.Float32bits(float32(v)))
+		}
+		_, err := writer.Write(buf)
+		return err
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	stdin.Close()
+	if waitErr := waitCommand(cmd); waitErr != nil {
+		return fmt.Errorf("failed to encode '%s': %v\nOutput: %s", outPath, waitErr, stderr.String())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write samples to '%s': %v", outPath, err)
+	}
+	return nil
+}
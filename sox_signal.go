@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// exitSignalled is added to the signal number for the exit status when the
+// run is interrupted by a signal, as shells do: 130 for SIGINT and 143 for
+// SIGTERM.
+const exitSignalled = 128
+
+// runCtx is the root context of all the external commands, cancelled when the
+// run is interrupted.
+var runCtx, cancelRun = context.WithCancel(context.Background())
+
+// interruption tracks the running commands, and the temp files and partial
+// outputs to remove, should the run be interrupted.
+var interruption = struct {
+	sync.Mutex
+	running map[*exec.Cmd]bool
+	paths   map[string]bool
+}{running: map[*exec.Cmd]bool{}, paths: map[string]bool{}}
+
+// ..........................................................................
+// handleSignals shuts the run down cleanly on SIGINT and SIGTERM: it cancels
+// runCtx and the running commands, killing their process groups, removes the
+// temp files and partial outputs, and exits with exitSignalled plus the
+// signal number.
+func handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		slog.Warn("Interrupted, shutting down", "signal", sig)
+		cancelRun()
+
+		interruption.Lock()
+		for cmd := range interruption.running {
+			cmd.Cancel()
+		}
+		for path := range interruption.paths {
+			slog.Debug("Removing", "path", path)
+			os.RemoveAll(path)
+		}
+		interruption.Unlock()
+
+		code := exitSignalled + 2
+		if s, ok := sig.(syscall.Signal); ok {
+			code = exitSignalled + int(s)
+		}
+		os.Exit(code)
+	}()
+}
+
+// removeOnInterrupt marks the path, a file or directory, to be removed should
+// the run be interrupted.
+func removeOnInterrupt(path string) {
+	interruption.Lock()
+	defer interruption.Unlock()
+	interruption.paths[path] = true
+}
+
+// keepOnInterrupt unmarks the path, once complete.
+func keepOnInterrupt(path string) {
+	interruption.Lock()
+	defer interruption.Unlock()
+	delete(interruption.paths, path)
+}
+
+// ..........................................................................
+// newCommand returns the external command to run under runCtx, in a process
+// group of its own so that it is killed together with its children.
+func newCommand(name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(runCtx, name, args...)
+	setProcessGroup(cmd)
+	return cmd
+}
+
+// startCommand starts the command made by newCommand, tracking it until
+// waitCommand. When the run is interrupted, it does not return but leaves the
+// exit to handleSignals.
+func startCommand(cmd *exec.Cmd) error {
+	interruption.Lock()
+	err := cmd.Start()
+	if err == nil {
+		interruption.running[cmd] = true
+	}
+	interruption.Unlock()
+	if err != nil && runCtx.Err() != nil {
+		select {}
+	}
+	return err
+}
+
+// waitCommand waits for the command started by startCommand. When the run is
+// interrupted, it does not return but leaves the exit to handleSignals.
+func waitCommand(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+	interruption.Lock()
+	delete(interruption.running, cmd)
+	interruption.Unlock()
+	if runCtx.Err() != nil {
+		select {}
+	}
+	return err
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Snap modes for the segment boundaries.
+const (
+	snapNone    = "none"
+	snapZero    = "zero"
+	snapEnergy  = "energy"
+	snapSilence = "silence"
+)
+
+// Envelope framing for the energy and silence snap modes.
+const (
+	snapFrame = 20 * time.Millisecond
+	snapHop   = 5 * time.Millisecond
+)
+
+// ..........................................................................
+// snapTimings moves each start and end time within the window to the
+// nearest zero crossing, local energy minimum or silence gap of the source,
+// depending on the mode. Boundaries with nothing to snap to stay as they are.
+func snapTimings(timings []ClipTiming, mode string, window time.Duration) ([]ClipTiming, error) {
+	var find func(mono []float64, center, rate int) int
+	switch mode {
+	case snapZero:
+		find = nearestZeroCrossing
+	case snapEnergy:
+		find = nearestEnergyMinimum
+	case snapSilence:
+		find = nearestSilenceGap
+	default:
+		return nil, fmt.Errorf("unknown snap mode '%s', expected %s, %s, %s or %s",
+			mode, snapNone, snapZero, snapEnergy, snapSilence)
+	}
+
+	format, err := getAudioFormat(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not get format of '%s': %v", inputFile, err)
+	}
+	snap := func(t time.Duration) (time.Duration, error) {
+		start := max(t-window, 0)
+		channels, err := readSamples(inputFile, format, start, t+window-start)
+		if err != nil {
+			return t, err
+		}
+		mono := mixdown(channels)
+		center := int((t - start).Seconds() * float64(format.Rate))
+		if center >= len(mono) {
+			return t, nil // Past the end of the source
+		}
+		i := find(mono, center, format.Rate)
+		if i < 0 {
+			return t, nil
+		}
+		return start + time.Duration(float64(i)/float64(format.Rate)*float64(time.Second)), nil
+	}
+
+	slog.Info("Snapping segment boundaries", "to", mode, "window", window)
+	snapped := append([]ClipTiming(nil), timings...)
+	for i, timing := range timings {
+		if snapped[i].Start, err = snap(timing.Start); err != nil {
+			return nil, err
+		}
+		if snapped[i].End, err = snap(timing.End); err != nil {
+			return nil, err
+		}
+		if snapped[i].Start >= snapped[i].End {
+			slog.Warn("Clip would be empty after snapping, left as is", "clip", i+1)
+			snapped[i] = timing
+		}
+		if snapped[i].Start != timing.Start {
+			slog.Info("Moved clip start", "clip", i+1, "from", timing.Start, "to", snapped[i].Start, "delta", snapped[i].Start-timing.Start)
+		}
+		if snapped[i].End != timing.End {
+			slog.Info("Moved clip end", "clip", i+1, "from", timing.End, "to", snapped[i].End, "delta", snapped[i].End-timing.End)
+		}
+	}
+	return snapped, nil
+}
+
+// nearestZeroCrossing returns the index of the sign change nearest to the
+// center, or -1 if there is none.
+func nearestZeroCrossing(mono []float64, center, rate int) int {
+	for d := 0; d < len(mono); d++ {
+		for _, i := range []int{center - d, center + d} {
+			if i > 0 && i < len(mono) && (mono[i-1] < 0) != (mono[i] < 0) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// nearestEnergyMinimum returns the index of the middle of the envelope frame
+// nearest to the center that is the quietest within 50 ms around it, or -1
+// if there is none.
+func nearestEnergyMinimum(mono []float64, center, rate int) int {
+	frameSize := int(snapFrame.Seconds() * float64(rate))
+	hop := int(snapHop.Seconds() * float64(rate))
+	envelope := rmsEnvelope(mono, frameSize, hop)
+	span := 5 // frames either side, 25 ms
+
+	centerFrame := max(center-frameSize/2, 0) / hop
+	for d := 0; d < len(envelope); d++ {
+		for _, f := range []int{centerFrame - d, centerFrame + d} {
+			if f < 0 || f >= len(envelope) {
+				continue
+			}
+			lowest := true
+			for g := max(f-span, 0); g <= min(f+span, len(envelope)-1); g++ {
+				if envelope[g] < envelope[f] {
+					lowest = false
+					break
+				}
+			}
+			if lowest {
+				return f*hop + frameSize/2
+			}
+		}
+	}
+	return -1
+}
+
+// nearestSilenceGap returns the index of the middle of the silence gap
+// nearest to the center, or -1 if there is none. Silence is at least 50 ms
+// of audio 30 dB below the loudest frame around.
+func nearestSilenceGap(mono []float64, center, rate int) int {
+	frameSize := int(snapFrame.Seconds() * float64(rate))
+	hop := int(snapHop.Seconds() * float64(rate))
+	envelope := rmsEnvelope(mono, frameSize, hop)
+	loudest := silenceDB
+	for _, level := range envelope {
+		loudest = max(loudest, level)
+	}
+	threshold := loudest - 30
+	minFrames := 10 // 50 ms
+
+	best, bestDist := -1, len(mono)
+	for f := 0; f < len(envelope); {
+		if envelope[f] > threshold {
+			f++
+			continue
+		}
+		g := f
+		for g < len(envelope) && envelope[g] <= threshold {
+			g++
+		}
+		if g-f >= minFrames {
+			mid := (f+g-1)/2*hop + frameSize/2
+			dist := mid - center
+			if dist < 0 {
+				dist = -dist
+			}
+			if dist < bestDist {
+				best, bestDist = mid, dist
+			}
+		}
+		f = g
+	}
+	return best
+}
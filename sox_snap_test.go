@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+const snapTestRate = 8000
+
+// tone returns n samples of a 440 Hz sine scaled by the gain of each sample.
+func tone(n int, gain func(i int) float64) []float64 {
+	samples := make([]float64, n)
+	for i := range samples {
+		samples[i] = gain(i) * math.Sin(2*math.Pi*440*float64(i)/snapTestRate)
+	}
+	return samples
+}
+
+func within(got, want, margin int) bool {
+	return got >= want-margin && got <= want+margin
+}
+
+func TestNearestZeroCrossing(t *testing.T) {
+	mono := []float64{0.5, 0.4, 0.3, 0.2, 0.1, -0.1, -0.2, -0.3, -0.4, 0.1, 0.2}
+	tests := []struct {
+		center, want int
+	}{
+		{5, 5},
+		{4, 5},
+		{7, 5}, // 5 and 9 are both 2 away, the earlier wins
+		{8, 9},
+		{10, 9},
+		{0, 5},
+	}
+	for _, tt := range tests {
+		if got := nearestZeroCrossing(mono, tt.center, snapTestRate); got != tt.want {
+			t.Errorf("nearestZeroCrossing(center %d) = %d, want %d", tt.center, got, tt.want)
+		}
+	}
+	if got := nearestZeroCrossing([]float64{0.1, 0.2, 0.3}, 1, snapTestRate); got != -1 {
+		t.Errorf("nearestZeroCrossing(no crossing) = %d, want -1", got)
+	}
+}
+
+func TestNearestEnergyMinimum(t *testing.T) {
+	// The level falls linearly to a minimum at 4000 and rises after it.
+	mono := tone(8000, func(i int) float64 {
+		return 0.01 + math.Abs(float64(i-4000))/4000
+	})
+	for _, center := range []int{3000, 4000, 5200} {
+		if got := nearestEnergyMinimum(mono, center, snapTestRate); !within(got, 4000, 100) {
+			t.Errorf("nearestEnergyMinimum(center %d) = %d, want 4000 ±100", center, got)
+		}
+	}
+	if got := nearestEnergyMinimum(mono[:100], 50, snapTestRate); got != -1 {
+		t.Errorf("nearestEnergyMinimum(shorter than a frame) = %d, want -1", got)
+	}
+}
+
+func TestNearestSilenceGap(t *testing.T) {
+	// Gaps of 125 ms at 1000-2000 and 6000-7000, and one of 25 ms, too short
+	// to count, at 4000-4200.
+	silent := func(i int) bool {
+		return i >= 1000 && i < 2000 || i >= 4000 && i < 4200 || i >= 6000 && i < 7000
+	}
+	mono := tone(8000, func(i int) float64 {
+		if silent(i) {
+			return 0
+		}
+		return 0.5
+	})
+	tests := []struct {
+		center, want int
+	}{
+		{1500, 1500},
+		{2500, 1500},
+		{3800, 1500},
+		{3900, 1500},
+		{4600, 6500},
+		{7900, 6500},
+	}
+	for _, tt := range tests {
+		if got := nearestSilenceGap(mono, tt.center, snapTestRate); !within(got, tt.want, 100) {
+			t.Errorf("nearestSilenceGap(center %d) = %d, want %d ±100", tt.center, got, tt.want)
+		}
+	}
+
+	loud := tone(8000, func(int) float64 { return 0.5 })
+	if got := nearestSilenceGap(loud, 4000, snapTestRate); got != -1 {
+		t.Errorf("nearestSilenceGap(no gap) = %d, want -1", got)
+	}
+}
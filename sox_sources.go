@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// audioExts holds the file extensions picked up when splicing a directory.
+var audioExts = map[string]bool{
+	".wav": true, ".flac": true, ".mp3": true, ".ogg": true, ".opus": true,
+	".aif": true, ".aiff": true, ".au": true, ".m4a": true, ".wv": true,
+}
+
+// ..........................................................................
+// readSources reads the sources to splice, either from the list file or
+// M3U/PLS playlist, or from the given directory / glob pattern.
+func readSources(listFile, dir string) ([]ListEntry, error) {
+	switch {
+	case listFile != "" && dir != "":
+		return nil, fmt.Errorf("the list file and the directory are mutually exclusive")
+	case dir != "":
+		return readDir(dir)
+	case listFile == "":
+		return nil, fmt.Errorf("either the list file or the directory is required")
+	}
+
+	switch strings.ToLower(filepath.Ext(listFile)) {
+	case ".m3u", ".m3u8":
+		return parseM3UFile(listFile)
+	case ".pls":
+		return parsePLSFile(listFile)
+	}
+	return parseListFile(listFile)
+}
+
+// ..........................................................................
+// readDir lists the sources in a directory, or matching a glob pattern such
+// as "parts/part-*.flac", in natural sort order. For a plain directory, only
+// files with known audio extensions are picked up.
+func readDir(dir string) ([]ListEntry, error) {
+	pattern, audioOnly := dir, false
+	if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+		pattern, audioOnly = filepath.Join(dir, "*"), true
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, path := range matches {
+		fi, err := os.Stat(path)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		if audioOnly && !audioExts[strings.ToLower(filepath.Ext(path))] {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool { return naturalLess(paths[i], paths[j]) })
+	return newListEntries(paths), nil
+}
+
+// ..........................................................................
+// parseM3UFile reads the M3U/M3U8 playlist, resolving relative paths against
+// the playlist's directory.
+func parseM3UFile(filePath string) ([]ListEntry, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") { // Skip empty lines, comments and directives
+			continue
+		}
+		path, err := playlistPath(filePath, line)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return newListEntries(paths), nil
+}
+
+// ..........................................................................
+// parsePLSFile reads the PLS playlist, ordering the sources by their FileN
+// key and resolving relative paths against the playlist's directory.
+func parsePLSFile(filePath string) ([]ListEntry, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	files := map[int]string{}
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || !strings.HasPrefix(strings.ToLower(key), "file") {
+			continue
+		}
+		n, err := strconv.Atoi(key[len("file"):])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid key '%s'", lineNumber, key)
+		}
+		path, err := playlistPath(filePath, strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		files[n] = path
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var keys []int
+	for n := range files {
+		keys = append(keys, n)
+	}
+	sort.Ints(keys)
+	var paths []string
+	for _, n := range keys {
+		paths = append(paths, files[n])
+	}
+	return newListEntries(paths), nil
+}
+
+// playlistPath resolves a playlist entry against the playlist's directory.
+func playlistPath(playlist, entry string) (string, error) {
+	if strings.HasPrefix(entry, "file://") {
+		u, err := url.Parse(entry)
+		if err != nil {
+			return "", fmt.Errorf("invalid file URL '%s': %w", entry, err)
+		}
+		return u.Path, nil
+	}
+	if strings.Contains(entry, "://") {
+		return "", fmt.Errorf("remote source '%s' is not supported", entry)
+	}
+	if filepath.IsAbs(entry) {
+		return entry, nil
+	}
+	return filepath.Join(filepath.Dir(playlist), entry), nil
+}
+
+// newListEntries creates list entries for the plain source paths.
+func newListEntries(paths []string) []ListEntry {
+	entries := make([]ListEntry, len(paths))
+	for i, path := range paths {
+		entries[i] = ListEntry{
+			Path:  path,
+			Joint: Joint{Excess: excessDuration, Leeway: leewayDuration},
+		}
+	}
+	return entries
+}
+
+// ..........................................................................
+// naturalLess compares strings with embedded numbers by their numeric value,
+// so that "part-2" sorts before "part-10".
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		ca, cb := a[0], b[0]
+		if isDigit(ca) && isDigit(cb) {
+			na, ra := splitDigits(a)
+			nb, rb := splitDigits(b)
+			ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+			if len(ta) != len(tb) {
+				return len(ta) < len(tb)
+			}
+			if ta != tb {
+				return ta < tb
+			}
+			if len(na) != len(nb) { // Same value, fewer leading zeros first
+				return len(na) < len(nb)
+			}
+			a, b = ra, rb
+			continue
+		}
+		if ca != cb {
+			return ca < cb
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// splitDigits splits the leading run of digits off s.
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"part-2", "part-10", true},
+		{"part-10", "part-2", false},
+		{"part-2", "part-2", false},
+		{"a", "b", true},
+		{"ep1", "ep1a", true},
+		{"ep01", "ep1", false},
+		{"ep1", "ep01", true},
+		{"ep007", "ep10", true},
+		{"2.wav", "10.wav", true},
+		{"take 9 final", "take 10", true},
+		{"", "a", true},
+	}
+	for _, tt := range tests {
+		if got := naturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// entryPaths returns the paths of the list entries.
+func entryPaths(entries []ListEntry) []string {
+	var paths []string
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+	return paths
+}
+
+func TestParseM3UFile(t *testing.T) {
+	path := writeFile(t, "show.m3u8", "\ufeff#EXTM3U\n#EXTINF:123,Intro\nintro.wav\n\n"+
+		"parts/part 1.flac\n/abs/part2.flac\nfile:///abs/part%203.flac\n")
+	entries, err := parseM3UFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Dir(path)
+	want := []string{filepath.Join(dir, "intro.wav"), filepath.Join(dir, "parts/part 1.flac"),
+		"/abs/part2.flac", "/abs/part 3.flac"}
+	if got := entryPaths(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseM3UFile() = %q, want %q", got, want)
+	}
+
+	path = writeFile(t, "remote.m3u", "https://example.com/a.mp3\n")
+	if _, err := parseM3UFile(path); err == nil {
+		t.Error("parseM3UFile() of a remote source succeeded, want an error")
+	}
+}
+
+func TestParsePLSFile(t *testing.T) {
+	path := writeFile(t, "show.pls", "[playlist]\nNumberOfEntries=3\n"+
+		"File10=c.wav\nTitle10=C\nFile2=b.wav\nfile1=/abs/a.wav\nVersion=2\n")
+	entries, err := parsePLSFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Dir(path)
+	want := []string{"/abs/a.wav", filepath.Join(dir, "b.wav"), filepath.Join(dir, "c.wav")}
+	if got := entryPaths(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePLSFile() = %q, want %q", got, want)
+	}
+
+	path = writeFile(t, "bad.pls", "[playlist]\nFileX=a.wav\n")
+	if _, err := parsePLSFile(path); err == nil {
+		t.Error("parsePLSFile() of an invalid key succeeded, want an error")
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of the stingers, as recorded in their joints.
+const (
+	stingerIntro = "intro"
+	stingerOutro = "outro"
+)
+
+// stingerDuck is how far the stinger is ducked where it overlaps the speech,
+// in dB.
+const stingerDuck = 12.0
+
+// The intro and outro jingles, and how long they overlap the speech.
+var (
+	introFile, outroFile string
+	overlapDuration      time.Duration
+)
+
+// ..........................................................................
+// attachStingers adds the intro and outro jingles, if any, around the clips
+// in the sample format of the first clip, with joints that mix them into the
+// speech by the overlap instead of splicing. The clips keep their numbering,
+// with the stinger joints told apart by their Stinger.
+func attachStingers(clipPaths []string, joints []Joint, tempDir string) ([]string, []Joint, error) {
+	if introFile == "" && outroFile == "" {
+		return clipPaths, joints, nil
+	}
+	format, err := getAudioFormat(clipPaths[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get format of '%s': %v", clipPaths[0], err)
+	}
+
+	// Convert them apart from the clips, as their temporary names would clash.
+	stingerDir := filepath.Join(tempDir, "stingers")
+	if err := os.MkdirAll(stingerDir, 0755); err != nil {
+		return nil, nil, err
+	}
+	var stingers []string
+	for _, stinger := range []string{introFile, outroFile} {
+		if stinger != "" {
+			stingers = append(stingers, stinger)
+		}
+	}
+	stingers, err = harmoniseClips(stingers, format, stingerDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to prepare the intro and outro: %v", err)
+	}
+
+	all := make([]Joint, len(clipPaths)-1)
+	for i := range all {
+		all[i] = jointAt(joints, i)
+	}
+	if introFile != "" {
+		clipPaths = append([]string{stingers[0]}, clipPaths...)
+		all = append([]Joint{{Stinger: stingerIntro, Overlap: overlapDuration}}, all...)
+	}
+	if outroFile != "" {
+		clipPaths = append(clipPaths, stingers[len(stingers)-1])
+		all = append(all, Joint{Stinger: stingerOutro, Overlap: overlapDuration})
+	}
+	return clipPaths, all, nil
+}
+
+// ..........................................................................
+// mixJoint mixes the next clip into the end of the combined file, at the
+// stinger joint, starting the joint overlap before its end, into outPath,
+// returning the resulting file. The stinger is ducked where it overlaps the
+// speech. The joint position is moved to where the next clip starts.
+func mixJoint(combinedFile, nextClip string, joint *Joint, outPath string) (string, error) {
+	end := joint.Position
+	joint.Position = max(joint.Position-joint.Overlap, 0)
+	slog.Info("Mixing stinger in", "stinger", joint.Stinger, "position", joint.Position)
+
+	// The intro is all of the combined file, the outro all of the next clip.
+	duckedPath := strings.TrimSuffix(outPath, ".wav") + "_ducked.wav"
+	var err error
+	if joint.Stinger == stingerIntro {
+		combinedFile, err = duckStinger(combinedFile, joint.Position, end, duckedPath)
+	} else {
+		nextClip, err = duckStinger(nextClip, 0, end-joint.Position, duckedPath)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to duck the %s: %v", joint.Stinger, err)
+	}
+
+	// Delay the next clip to its position, then mix both at unity gain.
+	delayedPath := strings.TrimSuffix(outPath, ".wav") + "_delayed.wav"
+	padArgs := []string{"pad", fmt.Sprintf("%f", joint.Position.Seconds()), "0"}
+	delayedPath, err = clipCache.cached(delayedPath, []string{nextClip}, padArgs, func() error {
+		if output, err := runCommand("sox", append([]string{nextClip, delayedPath}, padArgs...)...); err != nil {
+			return fmt.Errorf("failed to delay the %s joint: %v\nOutput: %s", joint.Stinger, err, string(output))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return clipCache.cached(outPath, []string{combinedFile, delayedPath},
+		append([]string{"-m", "-v", mixVolume}, mixLimiter()...), func() error {
+			if output, err := mixFiles(combinedFile, delayedPath, outPath); err != nil {
+				return fmt.Errorf("failed to mix the %s joint: %v\nOutput: %s", joint.Stinger, err, string(output))
+			}
+			return nil
+		})
+}
+
+// duckStinger ducks the stinger by stingerDuck dB from start to end, into
+// outPath, returning the resulting file.
+func duckStinger(stingerPath string, start, end time.Duration, outPath string) (string, error) {
+	format, err := getAudioFormat(stingerPath)
+	if err != nil {
+		return "", fmt.Errorf("could not get format of '%s': %v", stingerPath, err)
+	}
+	formatArgs := []string{"-r", strconv.Itoa(format.Rate), "-c", strconv.Itoa(format.Channels),
+		"-b", strconv.Itoa(wavBits(format.Bits))}
+	params := []string{"duck", fmt.Sprintf("%.2f", stingerDuck),
+		fmt.Sprintf("%f", start.Seconds()), fmt.Sprintf("%f", end.Seconds())}
+	return clipCache.cached(outPath, []string{stingerPath}, params, func() error {
+		var streamErr error
+		return encodeSamples(outPath, format.Rate, format.Channels, formatArgs, nil,
+			func(emit func(frame []float64) error) error {
+				i := 0
+				err := streamSamples(stingerPath, format, func(frame []float64) {
+					if streamErr != nil {
+						return
+					}
+					t := time.Duration(float64(i) / float64(format.Rate) * float64(time.Second))
+					gain := math.Pow(10, stingerGain(t, start, end)/20)
+					for c := range frame {
+						frame[c] *= gain
+					}
+					streamErr = emit(frame)
+					i++
+				})
+				if streamErr != nil {
+					return streamErr
+				}
+				return err
+			})
+	})
+}
+
+// stingerGain returns the gain of the stinger in dB at t: ducked from start
+// to end, ramping down ahead of it and back up after it, as the bed does.
+func stingerGain(t, start, end time.Duration) float64 {
+	var away time.Duration
+	if t < start {
+		away = start - t
+	} else if t > end {
+		away = t - end
+	}
+	return -stingerDuck * max(1-away.Seconds()/duckRamp.Seconds(), 0)
+}
+
+// setStingers sets the intro and outro jingles from the cli.
+func setStingers(intro, outro string, overlap int) {
+	introFile, outroFile = intro, outro
+	overlapDuration = time.Duration(overlap) * time.Millisecond
+	if intro != "" || outro != "" {
+		slog.Info("Attaching stingers", "intro", intro, "outro", outro, "overlap", overlapDuration)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestStingerGain(t *testing.T) {
+	start, end := 2*time.Second, 4*time.Second
+	tests := []struct {
+		t    time.Duration
+		want float64
+	}{
+		{0, 0},
+		{1700 * time.Millisecond, 0},
+		{1850 * time.Millisecond, -6},
+		{2 * time.Second, -12},
+		{3 * time.Second, -12},
+		{4 * time.Second, -12},
+		{4100 * time.Millisecond, -8},
+		{4300 * time.Millisecond, 0},
+		{10 * time.Second, 0},
+	}
+	for _, tt := range tests {
+		if got := stingerGain(tt.t, start, end); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("stingerGain(%v) = %g dB, want %g dB", tt.t, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Timeline is the map of where the joints landed in the spliced output,
+// saved next to it for later inspection, with the fades around it. Positions
+// are in seconds, on the spliced audio before any user effects.
+type Timeline struct {
+	Output    string            `json:"output"`
+	Duration  float64           `json:"duration"`
+	FadeIn    float64           `json:"fade_in,omitempty"`
+	FadeOut   float64           `json:"fade_out,omitempty"`
+	FadeCurve string            `json:"fade_curve,omitempty"`
+	Joints    []TimelineJoint   `json:"joints"`
+	Stingers  []TimelineStinger `json:"stingers,omitempty"`
+}
+
+// TimelineJoint is a single joint in the Timeline.
+type TimelineJoint struct {
+	Clip     int     `json:"clip"` // the clip spliced in at the joint, from 2
+	Position float64 `json:"position"`
+	Excess   float64 `json:"excess"`
+	Leeway   float64 `json:"leeway"`
+	Offset   float64 `json:"offset"`
+	Forced   bool    `json:"forced,omitempty"`
+	Gap      float64 `json:"gap,omitempty"` // inserted in place of the cross-fade
+}
+
+// TimelineStinger is the joint of the intro or outro in the Timeline, mixed
+// in instead of spliced.
+type TimelineStinger struct {
+	Stinger  string  `json:"stinger"`  // intro or outro
+	Position float64 `json:"position"` // where the clip after it starts
+	Overlap  float64 `json:"overlap"`
+}
+
+// Joint returns the joint parameters and position.
+func (j TimelineJoint) Joint() Joint {
+	return Joint{
+		Excess:   seconds(j.Excess),
+		Leeway:   seconds(j.Leeway),
+		Position: seconds(j.Position),
+		Offset:   seconds(j.Offset),
+		Forced:   j.Forced,
+		Gap:      Gap{Duration: seconds(j.Gap)},
+	}
+}
+
+// seconds converts seconds to a time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// timelinePath returns the path of the timeline map of the output file.
+func timelinePath(output string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output)) + ".timeline.json"
+}
+
+// ..........................................................................
+// writeTimeline saves the timeline map of the spliced joints and the fades of
+// the output of the given duration next to the output file.
+func writeTimeline(output string, joints []Joint, duration time.Duration, fade Fade) error {
+	timeline := Timeline{Output: output, Duration: duration.Seconds()}
+	if fade.In > 0 || fade.Out > 0 {
+		timeline.FadeIn, timeline.FadeOut = fade.In.Seconds(), fade.Out.Seconds()
+		timeline.FadeCurve = fade.Curve
+	}
+	timeline.Joints, timeline.Stingers = timelineJoints(joints)
+
+	data, err := json.MarshalIndent(timeline, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := timelinePath(output)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	slog.Info("Timeline map saved", "path", path)
+	return nil
+}
+
+// timelineJoints returns the joints as they are saved in the timeline map,
+// the joints between the clips, numbered as given, apart from the stingers.
+func timelineJoints(joints []Joint) ([]TimelineJoint, []TimelineStinger) {
+	tjs := []TimelineJoint{}
+	var stingers []TimelineStinger
+	for _, joint := range joints {
+		if joint.Stinger != "" {
+			stingers = append(stingers, TimelineStinger{
+				Stinger:  joint.Stinger,
+				Position: joint.Position.Seconds(),
+				Overlap:  joint.Overlap.Seconds(),
+			})
+			continue
+		}
+		tjs = append(tjs, TimelineJoint{
+			Clip:     len(tjs) + 2,
+			Position: joint.Position.Seconds(),
+			Excess:   joint.Excess.Seconds(),
+			Leeway:   joint.Leeway.Seconds(),
+			Offset:   joint.Offset.Seconds(),
+			Forced:   joint.Forced,
+			Gap:      joint.Gap.Duration.Seconds(),
+		})
+	}
+	return tjs, stingers
+}
+
+// readTimeline loads a timeline map.
+func readTimeline(path string) (*Timeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var timeline Timeline
+	if err := json.Unmarshal(data, &timeline); err != nil {
+		return nil, fmt.Errorf("invalid timeline map '%s': %w", path, err)
+	}
+	return &timeline, nil
+}
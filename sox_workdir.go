@@ -0,0 +1,136 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// workManifest is the name of the manifest file in the work directory.
+const workManifest = "manifest.json"
+
+// WorkDir keeps the intermediate files of a render in a named directory, with
+// a manifest of the steps completed, so that an interrupted render can resume
+// where it stopped.
+type WorkDir struct {
+	Dir   string              `json:"-"`
+	Steps map[string]WorkStep `json:"steps"` // keyed by the output, relative to Dir
+
+	hashes map[string]string // file path -> content hash or step key
+}
+
+// WorkStep is a completed step, with the key of its inputs and params, and
+// the size and modification time its output was left with.
+type WorkStep struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// workDir is the work directory in use for the run, nil when not asked for.
+var workDir *WorkDir
+
+// ..........................................................................
+// openWorkDir opens the work directory dir, creating it if needed. With
+// resume, the steps completed by an earlier render are reused; without, the
+// render starts over.
+func openWorkDir(dir string, resume bool) (*WorkDir, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	w := &WorkDir{Dir: dir, Steps: map[string]WorkStep{}, hashes: map[string]string{}}
+	if !resume {
+		return w, w.save()
+	}
+	data, err := os.ReadFile(filepath.Join(dir, workManifest))
+	if errors.Is(err, os.ErrNotExist) {
+		return w, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, fmt.Errorf("invalid work manifest in '%s': %w", dir, err)
+	}
+	if w.Steps == nil {
+		w.Steps = map[string]WorkStep{}
+	}
+	slog.Info("Resuming from the work directory", "dir", dir, "steps", len(w.Steps))
+	return w, nil
+}
+
+// run calls create to make outPath from the inputs with the params, unless
+// the step is already complete and its output intact, and records the step
+// in the manifest.
+func (w *WorkDir) run(outPath string, inputs, params []string, create func() error) error {
+	if w == nil {
+		return create()
+	}
+	name, err := filepath.Rel(w.Dir, outPath)
+	if err != nil {
+		return err
+	}
+	key, err := w.key(inputs, params)
+	if err != nil {
+		return err
+	}
+	if step, ok := w.Steps[name]; ok && step.Key == key {
+		if info, err := os.Stat(outPath); err == nil && info.Size() == step.Size && info.ModTime().Equal(step.ModTime) {
+			slog.Debug("Skipping completed step", "step", name)
+			w.hashes[outPath] = key
+			return nil
+		}
+		slog.Warn("Redoing step, its output changed", "step", name)
+	}
+
+	delete(w.Steps, name)
+	if err := create(); err != nil {
+		return err
+	}
+	info, err := os.Stat(outPath)
+	if err != nil {
+		return err
+	}
+	w.Steps[name] = WorkStep{Key: key, Size: info.Size(), ModTime: info.ModTime()}
+	w.hashes[outPath] = key
+	return w.save()
+}
+
+// key computes the step key for the inputs and params. The outputs of
+// earlier steps are known by their keys, so that they need no hashing.
+func (w *WorkDir) key(inputs, params []string) (string, error) {
+	h := sha256.New()
+	for _, input := range inputs {
+		hash, ok := w.hashes[input]
+		if !ok {
+			var err error
+			if hash, err = hashFile(input); err != nil {
+				return "", err
+			}
+			w.hashes[input] = hash
+		}
+		fmt.Fprintf(h, "%s\n", hash)
+	}
+	fmt.Fprintf(h, "%q\n", params)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// save writes the manifest atomically, so that a failed render leaves the
+// steps completed so far.
+func (w *WorkDir) save() error {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(w.Dir, workManifest)
+	if err := os.WriteFile(path+".tmp", append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWorkDirRun(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "work")
+	input := writeFile(t, "in.wav", "source")
+	outPath := filepath.Join(dir, "clip_1.wav")
+	made := 0
+	create := func() error {
+		made++
+		return os.WriteFile(outPath, []byte("clip"), 0644)
+	}
+
+	// run opens the work directory afresh or resumed, runs the step and
+	// checks whether create was called.
+	run := func(what string, resume bool, params []string, want bool) {
+		t.Helper()
+		w, err := openWorkDir(dir, resume)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := made
+		if err := w.run(outPath, []string{input}, params, create); err != nil {
+			t.Fatal(err)
+		}
+		if got := made > before; got != want {
+			t.Errorf("%s: step run %v, want %v", what, got, want)
+		}
+	}
+
+	params := []string{"trim", "1", "2"}
+	run("first run", false, params, true)
+	run("resumed, unchanged", true, params, false)
+	run("resumed again", true, params, false)
+	run("not resumed", false, params, true)
+	run("other params", true, []string{"trim", "1", "3"}, true)
+	run("back to the params", true, params, true)
+
+	// A changed output size, or modification time, redoes the step.
+	if err := os.WriteFile(outPath, []byte("truncated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("output size changed", true, params, true)
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(outPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	run("output touched", true, params, true)
+	if err := os.Remove(outPath); err != nil {
+		t.Fatal(err)
+	}
+	run("output removed", true, params, true)
+
+	// So does a changed input.
+	if err := os.WriteFile(input, []byte("new source"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("input changed", true, params, true)
+	run("resumed after the change", true, params, false)
+}
+
+func TestWorkDirRunNil(t *testing.T) {
+	var w *WorkDir
+	made := 0
+	err := w.run("out.wav", nil, nil, func() error {
+		made++
+		return nil
+	})
+	if err != nil || made != 1 {
+		t.Errorf("nil work dir: made %d times, err %v; want made once", made, err)
+	}
+}
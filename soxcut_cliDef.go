@@ -80,13 +80,41 @@ import (
 
 // The OptsT type defines all the configurable options from cli.
 type OptsT struct {
-	DurExcess int    `short:"E" long:"excess" env:"SOXCUT_DUREXCESS" description:"excess duration of the cross-fade overlap in ms" default:"500"`
-	DurLeeway int    `short:"L" long:"leeway" env:"SOXCUT_DURLEEWAY" description:"leeway duration for finding best splice point in ms" default:"200"`
-	FileO     string `short:"o" long:"output" env:"SOXCUT_FILEO" description:"the final output file" default:"output.mp3"`
-	FmtOpt    string `short:"f" long:"fopts" env:"SOXCUT_FMTOPT" description:"fopts (format options) for the output file"`
-	Verbflg   func() `short:"v" long:"verbose" description:"Verbose mode (Multiple -v options increase the verbosity)"`
-	Verbose   int
-	Version   func() `short:"V" long:"version" description:"Show program version and exit"`
+	DurExcess      int      `short:"E" long:"excess" env:"SOXCUT_DUREXCESS" description:"excess duration of the cross-fade overlap in ms" default:"500"`
+	DurLeeway      int      `short:"L" long:"leeway" env:"SOXCUT_DURLEEWAY" description:"leeway duration for finding best splice point in ms" default:"200"`
+	FileO          []string `short:"o" long:"output" env:"SOXCUT_FILEO" env-delim:"," description:"the final output file, as file[:fopts] (repeatable, encoded concurrently)" default:"output.mp3"`
+	FmtOpt         string   `short:"f" long:"fopts" env:"SOXCUT_FMTOPT" description:"fopts (format options) for the output file"`
+	Preset         string   `short:"Z" long:"preset" env:"SOXCUT_PRESET" description:"the encoder preset of fopts and final effects, podcast-mp3, archive-flac, voice-opus or one from ~/.config/soxcut/presets.yaml"`
+	CacheDir       string   `short:"C" long:"cache-dir" env:"SOXCUT_CACHEDIR" description:"the directory to keep and reuse intermediate files in"`
+	MatchLoudness  bool     `short:"M" long:"match-loudness" env:"SOXCUT_MATCHLOUDNESS" description:"match the loudness of all clips before splicing"`
+	MaxBoost       float64  `short:"B" long:"max-boost" env:"SOXCUT_MAXBOOST" description:"maximum boost in dB when matching loudness" default:"6"`
+	LoudnessTarget float64  `short:"T" long:"loudness-target" env:"SOXCUT_LOUDNESSTARGET" description:"normalise the output to this integrated loudness in LUFS, e.g. -16"`
+	TruePeak       float64  `short:"P" long:"true-peak" env:"SOXCUT_TRUEPEAK" description:"the true peak limit in dBTP when normalising loudness" default:"-1"`
+	Timeline       bool     `short:"j" long:"timeline" env:"SOXCUT_TIMELINE" description:"write the timeline map of the joints next to the output file"`
+	Snap           string   `short:"n" long:"snap" env:"SOXCUT_SNAP" description:"snap segment boundaries to the nearest zero, energy (minimum) or silence (gap)" choice:"none" choice:"zero" choice:"energy" choice:"silence" default:"none"`
+	SnapWindow     int      `short:"W" long:"snap-window" env:"SOXCUT_SNAPWINDOW" description:"the window either side of a boundary to snap within in ms" default:"100"`
+	Search         string   `short:"A" long:"search" env:"SOXCUT_SEARCH" description:"where to search the best splice point within the leeway, native or by the sox splice effect" choice:"native" choice:"sox" default:"sox"`
+	EnvelopeWeight float64  `short:"e" long:"envelope-weight" env:"SOXCUT_ENVELOPEWEIGHT" description:"weight (0-1) of the envelope similarity in the native splice point search"`
+	ForceOffset    []string `short:"F" long:"force-offset" env:"SOXCUT_FORCEOFFSET" env-delim:"," description:"force the splice offset of the joint before clip N, as N=ms (repeatable)"`
+	RoomTone       string   `short:"R" long:"room-tone" env:"SOXCUT_ROOMTONE" description:"region of the source with the room tone to fill gaps and padding with, as START-END, or auto to detect the quietest stretch"`
+	Pad            string   `short:"D" long:"pad" env:"SOXCUT_PAD" description:"room tone, or silence, to pad the head and tail of the output with, as HEAD[,TAIL] in ms"`
+	KeepTiming     bool     `short:"K" long:"keep-timing" env:"SOXCUT_KEEPTIMING" description:"fill the regions removed between segments with the room tone, or silence without one, keeping the original timing"`
+	Bed            string   `short:"m" long:"bed" env:"SOXCUT_BED" description:"music to loop or trim to the output and mix under it"`
+	BedGain        float64  `short:"g" long:"bed-gain" env:"SOXCUT_BEDGAIN" description:"gain of the music bed in dB" default:"-18"`
+	Duck           float64  `short:"u" long:"duck" env:"SOXCUT_DUCK" description:"how far to duck the music bed under speech in dB" default:"12"`
+	BedFade        int      `short:"x" long:"bed-fade" env:"SOXCUT_BEDFADE" description:"fade in and out duration of the music bed in ms" default:"3000"`
+	FadeIn         int      `short:"H" long:"fade-in" env:"SOXCUT_FADEIN" description:"fade-in duration at the head of the output in ms"`
+	FadeOut        int      `short:"Q" long:"fade-out" env:"SOXCUT_FADEOUT" description:"fade-out duration at the tail of the output in ms"`
+	FadeCurve      string   `short:"k" long:"fade-curve" env:"SOXCUT_FADECURVE" description:"curve of the head and tail fades" choice:"linear" choice:"quarter-sine" choice:"half-sine" choice:"logarithmic" choice:"parabola" default:"linear"`
+	Progress       string   `short:"G" long:"progress" env:"SOXCUT_PROGRESS" description:"progress reporting, a bar on stderr, json events on stdout, none, or auto for a bar on a terminal" choice:"auto" choice:"bar" choice:"json" choice:"none" default:"auto"`
+	LogFormat      string   `short:"J" long:"log-format" env:"SOXCUT_LOGFORMAT" description:"log format on stderr, text or json" choice:"text" choice:"json" default:"text"`
+	Report         string   `short:"Y" long:"report" env:"SOXCUT_REPORT" description:"the report file to write of the run, with its options, inputs, trims, joints, commands and outputs"`
+	WorkDir        string   `short:"U" long:"work-dir" env:"SOXCUT_WORKDIR" description:"the directory to keep the intermediate files in, with a manifest of the steps completed"`
+	Resume         bool     `short:"y" long:"resume" env:"SOXCUT_RESUME" description:"resume the render in the work directory, skipping the steps already completed"`
+	NoClobber      bool     `short:"N" long:"no-clobber" env:"SOXCUT_NOCLOBBER" description:"refuse to overwrite existing output files"`
+	Verbflg        func()   `short:"v" long:"verbose" description:"Verbose mode (Multiple -v options increase the verbosity)"`
+	Verbose        int
+	Version        func() `short:"V" long:"version" description:"Show program version and exit"`
 }
 
 // Template for type define ends here
@@ -114,8 +142,11 @@ type OptsT struct {
 
 // The ExtractCommand type defines all the configurable options from cli.
 //  type ExtractCommand struct {
-//  	FileI	string	`short:"i" long:"input" env:"SOXCUT_FILEI" description:"the source to cut from (mandatory)" required:"true"`
-//  	FileS	string	`short:"s" long:"segments" env:"SOXCUT_FILES" description:"the segments definition file (mandatory)" required:"true"`
+//  	FileI	string	`short:"i" long:"input" env:"SOXCUT_FILEI" description:"the source to cut from"`
+//  	FileS	string	`short:"s" long:"segments" env:"SOXCUT_FILES" description:"the segments definition file"`
+//  	Intro	string	`short:"I" long:"intro" env:"SOXCUT_INTRO" description:"the jingle to attach to the start, its tail running under the speech"`
+//  	Outro	string	`short:"O" long:"outro" env:"SOXCUT_OUTRO" description:"the jingle to attach to the end, its head running under the speech"`
+//  	Overlap	int	`short:"X" long:"overlap" env:"SOXCUT_OVERLAP" description:"how long the intro and outro overlap the speech in ms" default:"2000"`
 //  }
 
 //
@@ -131,6 +162,7 @@ type OptsT struct {
 //    soxcut extract -i <inputFile> -s <segmentsFile> [-o <outputFile>] [sox_effects...]
 //    soxcut extract -i input1.wav -s timings.txt -o output.mp3 -f="-C 128"
 //    soxcut extract -i audio.flac -s timings.txt -o final.opus -f="-C 16" -v -- gain -n highpass 80 pad 0 5
+//    soxcut extract -i audio.flac -s timings.txt -o "show.mp3:-C 128" -o "show.opus:-C 48" -o master.flac
 
 //  `,
 //  		&extractCommand)
@@ -141,7 +173,7 @@ type OptsT struct {
 //   	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
 //   	clis.Setup("soxcut::extract", Opts.Verbose)
 //   	clis.Verbose(1, "Doing Extract, with %+v, %+v", Opts, args)
-//   	// fmt.Println(x.FileI, x.FileS)
+//   	// fmt.Println(x.FileI, x.FileS, x.Intro, x.Outro, x.Overlap)
 //  	return x.Exec(args)
 //  }
 //
@@ -178,7 +210,14 @@ type OptsT struct {
 
 // The SpliceCommand type defines all the configurable options from cli.
 //  type SpliceCommand struct {
-//  	FileList	string	`short:"l" long:"list" env:"SOXCUT_FILELIST" description:"the list file containing sources to splice (mandatory)" required:"true"`
+//  	FileList	string	`short:"l" long:"list" env:"SOXCUT_FILELIST" description:"the list file or M3U/PLS playlist containing sources to splice"`
+//  	Dir	string	`short:"d" long:"dir" env:"SOXCUT_DIR" description:"the directory or glob pattern of sources to splice, in natural sort order"`
+//  	Rate	int	`short:"r" long:"rate" env:"SOXCUT_RATE" description:"the sample rate to splice at, highest of the sources by default"`
+//  	Channels	int	`short:"c" long:"channels" env:"SOXCUT_CHANNELS" description:"the number of channels to splice with, highest of the sources by default"`
+//  	Bits	int	`short:"b" long:"bits" env:"SOXCUT_BITS" description:"the bit depth to splice with, highest of the sources by default"`
+//  	Intro	string	`short:"I" long:"intro" env:"SOXCUT_INTRO" description:"the jingle to attach to the start, its tail running under the speech"`
+//  	Outro	string	`short:"O" long:"outro" env:"SOXCUT_OUTRO" description:"the jingle to attach to the end, its head running under the speech"`
+//  	Overlap	int	`short:"X" long:"overlap" env:"SOXCUT_OVERLAP" description:"how long the intro and outro overlap the speech in ms" default:"2000"`
 //  }
 
 //
@@ -193,6 +232,8 @@ type OptsT struct {
 //  		`Example:
 //    soxcut splice -l <listFile> [-o <outputFile>] [sox_effects...]
 //    soxcut splice -l audio-files.lst -E 200 -L 100 -- compand 0.3,1 6:-70,-60,-20,-10,-5,-5 0 -90 0.1 rate 96k pad 0.5 15
+//    soxcut splice -l test/sources.lst -o show.mp3
+//    soxcut splice -d 'parts/part-*.flac' -o show.flac
 
 //  `,
 //  		&spliceCommand)
@@ -203,7 +244,7 @@ type OptsT struct {
 //   	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
 //   	clis.Setup("soxcut::splice", Opts.Verbose)
 //   	clis.Verbose(1, "Doing Splice, with %+v, %+v", Opts, args)
-//   	// fmt.Println(x.FileList)
+//   	// fmt.Println(x.FileList, x.Dir, x.Rate, x.Channels, x.Bits, x.Intro, x.Outro, x.Overlap)
 //  	return x.Exec(args)
 //  }
 //
@@ -216,3 +257,328 @@ type OptsT struct {
 // 	return nil
 // }
 // Template for "splice" CLI handling ends here
+
+// Template for "cache" CLI handling starts here
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+//  package main
+
+//  import (
+//  	"fmt"
+//  	"os"
+//
+//  	"github.com/go-easygen/go-flags/clis"
+//  )
+
+// *** Sub-command: cache ***
+
+////////////////////////////////////////////////////////////////////////////
+// Constant and data type/structure definitions
+
+// The CacheCommand type defines all the configurable options from cli.
+//  type CacheCommand struct {
+//  	MaxAge	int	`short:"a" long:"max-age" env:"SOXCUT_MAXAGE" description:"remove cached files not used for this many days (0 for no limit)" default:"30"`
+//  	MaxSize	int	`short:"s" long:"max-size" env:"SOXCUT_MAXSIZE" description:"remove least recently used files till the cache is within this many MB (0 for no limit)"`
+//  }
+
+//
+//  var cacheCommand CacheCommand
+//
+//  ////////////////////////////////////////////////////////////////////////////
+//  // Function definitions
+//
+//  func init() {
+//  	gfParser.AddCommand("cache",
+//  		"manage the cache of intermediate files",
+//  		`Example:
+//    soxcut cache -C <cacheDir> prune [-a <days>] [-s <MB>]
+//    soxcut cache -C ~/.cache/soxcut prune -a 7 -s 2000
+
+//  `,
+//  		&cacheCommand)
+//  }
+//
+//  func (x *CacheCommand) Execute(args []string) error {
+//   	fmt.Fprintf(os.Stderr, "manage the cache of intermediate files\n")
+//   	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
+//   	clis.Setup("soxcut::cache", Opts.Verbose)
+//   	clis.Verbose(1, "Doing Cache, with %+v, %+v", Opts, args)
+//   	// fmt.Println(x.MaxAge, x.MaxSize)
+//  	return x.Exec(args)
+//  }
+//
+// // Exec implements the business logic of command `cache`
+// func (x *CacheCommand) Exec(args []string) error {
+// 	// err := ...
+// 	// clis.WarnOn("cache::Exec", err)
+// 	// or,
+// 	// clis.AbortOn("cache::Exec", err)
+// 	return nil
+// }
+// Template for "cache" CLI handling ends here
+
+// Template for "preview" CLI handling starts here
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+//  package main
+
+//  import (
+//  	"fmt"
+//  	"os"
+//
+//  	"github.com/go-easygen/go-flags/clis"
+//  )
+
+// *** Sub-command: preview ***
+
+////////////////////////////////////////////////////////////////////////////
+// Constant and data type/structure definitions
+
+// The PreviewCommand type defines all the configurable options from cli.
+//  type PreviewCommand struct {
+//  	FileI	string	`short:"i" long:"input" env:"SOXCUT_FILEI" description:"the source to cut from"`
+//  	FileS	string	`short:"s" long:"segments" env:"SOXCUT_FILES" description:"the segments definition file"`
+//  	FileList	string	`short:"l" long:"list" env:"SOXCUT_FILELIST" description:"the list file or M3U/PLS playlist containing sources to splice"`
+//  	Dir	string	`short:"d" long:"dir" env:"SOXCUT_DIR" description:"the directory or glob pattern of sources to splice, in natural sort order"`
+//  	Before	int	`short:"b" long:"before" env:"SOXCUT_BEFORE" description:"duration to render before each joint in ms" default:"3000"`
+//  	After	int	`short:"a" long:"after" env:"SOXCUT_AFTER" description:"duration to render after each joint in ms" default:"3000"`
+//  	Separator	string	`short:"p" long:"separator" env:"SOXCUT_SEPARATOR" description:"what to put between the joint previews, beep or silence" default:"beep"`
+//  	Split	bool	`short:"S" long:"split" env:"SOXCUT_SPLIT" description:"write one numbered output file per joint instead"`
+//  }
+
+//
+//  var previewCommand PreviewCommand
+//
+//  ////////////////////////////////////////////////////////////////////////////
+//  // Function definitions
+//
+//  func init() {
+//  	gfParser.AddCommand("preview",
+//  		"render only the audio around each joint for quick auditioning",
+//  		`Example:
+//    soxcut preview -i <inputFile> -s <segmentsFile> [-o <outputFile>] [sox_effects...]
+//    soxcut preview -l <listFile> [-b <ms>] [-a <ms>] [-p beep|silence] [-S] [-o <outputFile>]
+//    soxcut preview -i input1.wav -s timings.txt -b 2000 -a 2000 -o joints.mp3
+//    soxcut preview -l test/sources.lst -S -o joint.wav
+
+//  `,
+//  		&previewCommand)
+//  }
+//
+//  func (x *PreviewCommand) Execute(args []string) error {
+//   	fmt.Fprintf(os.Stderr, "render only the audio around each joint for quick auditioning\n")
+//   	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
+//   	clis.Setup("soxcut::preview", Opts.Verbose)
+//   	clis.Verbose(1, "Doing Preview, with %+v, %+v", Opts, args)
+//   	// fmt.Println(x.FileI, x.FileS, x.FileList, x.Dir, x.Before, x.After, x.Separator, x.Split)
+//  	return x.Exec(args)
+//  }
+//
+// // Exec implements the business logic of command `preview`
+// func (x *PreviewCommand) Exec(args []string) error {
+// 	// err := ...
+// 	// clis.WarnOn("preview::Exec", err)
+// 	// or,
+// 	// clis.AbortOn("preview::Exec", err)
+// 	return nil
+// }
+// Template for "preview" CLI handling ends here
+
+// Template for "check-joints" CLI handling starts here
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+//  package main
+
+//  import (
+//  	"fmt"
+//  	"os"
+//
+//  	"github.com/go-easygen/go-flags/clis"
+//  )
+
+// *** Sub-command: check-joints ***
+
+////////////////////////////////////////////////////////////////////////////
+// Constant and data type/structure definitions
+
+// The CheckJointsCommand type defines all the configurable options from cli.
+//  type CheckJointsCommand struct {
+//  	Rendered	string	`short:"i" long:"rendered" env:"SOXCUT_RENDERED" description:"the rendered output to check (mandatory)" required:"true"`
+//  	MapFile	string	`short:"t" long:"map" env:"SOXCUT_MAPFILE" description:"the timeline map of the rendered output, next to it by default"`
+//  	Window	int	`short:"w" long:"window" env:"SOXCUT_WINDOW" description:"duration to compare before and after each cross-fade in ms" default:"500"`
+//  	Threshold	float64	`short:"s" long:"threshold" env:"SOXCUT_THRESHOLD" description:"the score from which a joint is flagged as suspicious" default:"1"`
+//  }
+
+//
+//  var checkJointsCommand CheckJointsCommand
+//
+//  ////////////////////////////////////////////////////////////////////////////
+//  // Function definitions
+//
+//  func init() {
+//  	gfParser.AddCommand("check-joints",
+//  		"inspect each joint of the rendered output for audible splices",
+//  		`Example:
+//    soxcut check-joints -i <renderedFile> [-t <timelineFile>] [-w <ms>] [-s <score>]
+//    soxcut extract -i input1.wav -s timings.txt -o output.wav --timeline && soxcut check-joints -i output.wav
+
+//  `,
+//  		&checkJointsCommand)
+//  }
+//
+//  func (x *CheckJointsCommand) Execute(args []string) error {
+//   	fmt.Fprintf(os.Stderr, "inspect each joint of the rendered output for audible splices\n")
+//   	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
+//   	clis.Setup("soxcut::check-joints", Opts.Verbose)
+//   	clis.Verbose(1, "Doing CheckJoints, with %+v, %+v", Opts, args)
+//   	// fmt.Println(x.Rendered, x.MapFile, x.Window, x.Threshold)
+//  	return x.Exec(args)
+//  }
+//
+// // Exec implements the business logic of command `check-joints`
+// func (x *CheckJointsCommand) Exec(args []string) error {
+// 	// err := ...
+// 	// clis.WarnOn("check-joints::Exec", err)
+// 	// or,
+// 	// clis.AbortOn("check-joints::Exec", err)
+// 	return nil
+// }
+// Template for "check-joints" CLI handling ends here
+
+// Template for "config" CLI handling starts here
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+//  package main
+
+//  import (
+//  	"fmt"
+//  	"os"
+//
+//  	"github.com/go-easygen/go-flags/clis"
+//  )
+
+// *** Sub-command: config ***
+
+////////////////////////////////////////////////////////////////////////////
+// Constant and data type/structure definitions
+
+// The ConfigCommand type defines all the configurable options from cli.
+//  type ConfigCommand struct {
+
+//  }
+
+//
+//  var configCommand ConfigCommand
+//
+//  ////////////////////////////////////////////////////////////////////////////
+//  // Function definitions
+//
+//  func init() {
+//  	gfParser.AddCommand("config",
+//  		"show the effective configuration",
+//  		`Example:
+//    soxcut config show
+//    soxcut -E 300 config show
+//    soxcut config show check-joints
+
+//  `,
+//  		&configCommand)
+//  }
+//
+//  func (x *ConfigCommand) Execute(args []string) error {
+//   	fmt.Fprintf(os.Stderr, "show the effective configuration\n")
+//   	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
+//   	clis.Setup("soxcut::config", Opts.Verbose)
+//   	clis.Verbose(1, "Doing Config, with %+v, %+v", Opts, args)
+//   	// fmt.Println()
+//  	return x.Exec(args)
+//  }
+//
+// // Exec implements the business logic of command `config`
+// func (x *ConfigCommand) Exec(args []string) error {
+// 	// err := ...
+// 	// clis.WarnOn("config::Exec", err)
+// 	// or,
+// 	// clis.AbortOn("config::Exec", err)
+// 	return nil
+// }
+// Template for "config" CLI handling ends here
+
+// Template for "batch" CLI handling starts here
+////////////////////////////////////////////////////////////////////////////
+// Program: soxcut
+// Purpose: sox wrapper tool
+// Authors: Tong Sun (c) 2025-2025, All rights reserved
+////////////////////////////////////////////////////////////////////////////
+
+//  package main
+
+//  import (
+//  	"fmt"
+//  	"os"
+//
+//  	"github.com/go-easygen/go-flags/clis"
+//  )
+
+// *** Sub-command: batch ***
+
+////////////////////////////////////////////////////////////////////////////
+// Constant and data type/structure definitions
+
+// The BatchCommand type defines all the configurable options from cli.
+//  type BatchCommand struct {
+//  	Manifest	string	`short:"i" long:"manifest" env:"SOXCUT_MANIFEST" description:"the manifest of jobs, a CSV or YAML file (mandatory)" required:"true"`
+//  	Concurrency	int	`short:"c" long:"concurrency" env:"SOXCUT_CONCURRENCY" description:"how many jobs to run at once, 0 for the number of CPUs"`
+//  }
+
+//
+//  var batchCommand BatchCommand
+//
+//  ////////////////////////////////////////////////////////////////////////////
+//  // Function definitions
+//
+//  func init() {
+//  	gfParser.AddCommand("batch",
+//  		"run the extract and splice jobs of a manifest",
+//  		`Example:
+//    soxcut batch -i episodes.csv
+//    soxcut -v batch -i episodes.yaml -c 4
+
+//  `,
+//  		&batchCommand)
+//  }
+//
+//  func (x *BatchCommand) Execute(args []string) error {
+//   	fmt.Fprintf(os.Stderr, "run the extract and splice jobs of a manifest\n")
+//   	// fmt.Fprintf(os.Stderr, "Copyright (C) 2025-2025, Tong Sun\n\n")
+//   	clis.Setup("soxcut::batch", Opts.Verbose)
+//   	clis.Verbose(1, "Doing Batch, with %+v, %+v", Opts, args)
+//   	// fmt.Println(x.Manifest, x.Concurrency)
+//  	return x.Exec(args)
+//  }
+//
+// // Exec implements the business logic of command `batch`
+// func (x *BatchCommand) Exec(args []string) error {
+// 	// err := ...
+// 	// clis.WarnOn("batch::Exec", err)
+// 	// or,
+// 	// clis.AbortOn("batch::Exec", err)
+// 	return nil
+// }
+// Template for "batch" CLI handling ends here
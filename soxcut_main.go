@@ -51,12 +51,16 @@ func main() {
 		Opts.Verbose++
 	}
 
+	handleSignals()
+	if err := loadConfig(gfParser); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", progname, err)
+		os.Exit(1)
+	}
 	if _, err := gfParser.Parse(); err != nil {
-		fmt.Println()
-		gfParser.WriteHelp(os.Stdout)
+		fmt.Fprintln(os.Stderr)
+		gfParser.WriteHelp(os.Stderr)
 		os.Exit(1)
 	}
-	fmt.Println()
 	//DoSoxcut()
 }
 